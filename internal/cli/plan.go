@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/llm"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/prompts"
+	"github.com/daydemir/ralph/internal/repair"
+	"github.com/daydemir/ralph/internal/utils"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	planModel string
+	planDiff  bool
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [context]",
+	Short: "Plan PRDs in an interactive Claude session",
+	Long: `Open an interactive Claude session to plan PRDs into .ralph/prd.json.
+
+Any arguments are passed to Claude as context for the session. When the
+session ends Ralph compares the backlog with its state beforehand, fills in
+IDs, timestamps and defaults the new PRDs left out, validates every added or
+changed PRD, and prints a summary.
+
+Examples:
+  ralph plan
+  ralph plan "add rate limiting to the public API"
+  ralph plan --diff`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(workspaceDir)
+		if err != nil {
+			return err
+		}
+
+		path := workspace.PRDPath(workspaceDir)
+		before, err := repair.Snapshot(path)
+		if err != nil {
+			return err
+		}
+
+		prompt, err := prompts.GetForWorkspace(workspaceDir, "plan.md")
+		if err != nil {
+			return fmt.Errorf("failed to load plan prompt: %w", err)
+		}
+		if len(args) > 0 {
+			prompt += "\n<user-context>\n" + strings.Join(args, " ") + "\n</user-context>\n"
+		}
+
+		model := planModel
+		if model == "" {
+			model = cfg.LLM.Model
+		}
+		claude := llm.NewClaude(cfg.Claude.Binary)
+		resume := passInterrupts()
+		err = claude.ExecuteInteractive(cmd.Context(), llm.ExecuteOptions{
+			Prompt:       prompt,
+			Model:        model,
+			AllowedTools: cfg.Claude.AllowedTools,
+			WorkDir:      workspaceDir,
+		})
+		resume()
+		// PRDs written before the session ended still get validated and reported
+		sessionErr := err
+		if sessionErr != nil {
+			sessionErr = fmt.Errorf("plan session failed: %w", sessionErr)
+		}
+
+		// The session may call ralph itself, so only the final save is locked
+		lock, err := workspace.AcquireLock(workspaceDir)
+		if err != nil {
+			return errors.Join(sessionErr, err)
+		}
+		defer lock.Release()
+		if err := reconcileBacklog(display.New(), path, before); err != nil {
+			return errors.Join(sessionErr, err)
+		}
+		return sessionErr
+	},
+}
+
+// reconcileBacklog fills in defaults for PRDs added or changed since before,
+// validates them and summarizes the changes
+func reconcileBacklog(d *display.Display, path string, before []byte) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		d.Info("Backlog", "no prd.json written")
+		return nil
+	}
+	backlog, err := prd.LoadBacklog(path)
+	if err != nil {
+		return err
+	}
+
+	var previous *prd.Backlog
+	if before != nil {
+		previous = &prd.Backlog{}
+		if err := json.Unmarshal(before, previous); err != nil {
+			previous = nil
+		}
+	}
+
+	filled := false
+	for _, p := range backlog.Features {
+		if p == nil || (previous != nil && previous.Find(p.ID) != nil) {
+			continue
+		}
+		if p.FillDefaults() {
+			filled = true
+		}
+	}
+	if filled {
+		if err := backlog.Save(path); err != nil {
+			return err
+		}
+	}
+
+	diff := prd.DiffBacklogs(previous, backlog)
+	if diff.IsEmpty() {
+		d.Info("Backlog", "no changes")
+		return nil
+	}
+
+	if planDiff {
+		after, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		patch, err := utils.UnifiedDiff("prd.json", before, after)
+		if err != nil {
+			return err
+		}
+		fmt.Print(patch)
+	}
+
+	for _, id := range diff.Added {
+		d.Success(fmt.Sprintf("added   %s  %s", id, backlog.Find(id).Title))
+	}
+	for _, id := range diff.Changed {
+		d.Info("Changed", fmt.Sprintf("%s  %s", id, backlog.Find(id).Title))
+	}
+	for _, id := range diff.Removed {
+		d.Warning(fmt.Sprintf("removed %s", id))
+	}
+
+	if errs := prd.ValidateBacklogFile(path, before); errs.HasErrors() {
+		d.Error(fmt.Sprintf("backlog has %d validation errors:", len(errs.Errors)))
+		fmt.Print(errs.ToPrompt())
+		return fmt.Errorf("fix .ralph/prd.json or run 'ralph plan' again")
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	planCmd.Flags().StringVarP(&planModel, "model", "m", "", "Model to use for the session (sonnet, opus, haiku)")
+	planCmd.Flags().BoolVar(&planDiff, "diff", false, "Print a unified diff of prd.json after the session")
+}
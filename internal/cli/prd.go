@@ -0,0 +1,647 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// prdFields holds the flags shared by prd add and prd edit
+type prdFields struct {
+	title         string
+	description   string
+	criteria      []string
+	steps         []string
+	dependsOn     []string
+	relatedFiles  []string
+	tags          []string
+	tests         []string
+	builds        []string
+	typeChecks    []string
+	custom        []string
+	maxIterations int
+}
+
+var (
+	prdAddFields  prdFields
+	prdEditFields prdFields
+	prdListStatus string
+	prdOutput     string
+	prdRmForce    bool
+
+	importFormat      string
+	importDryRun      bool
+	importSkipInvalid bool
+	importTests       []string
+	importBuilds      []string
+)
+
+var prdCmd = &cobra.Command{
+	Use:   "prd",
+	Short: "Add, inspect and edit PRDs in the backlog",
+	Long: `Manage .ralph/prd.json without asking the model or hand-editing JSON.
+
+Every change is validated before it is saved.`,
+}
+
+var prdAddCmd = &cobra.Command{
+	Use:   "add <title>",
+	Short: "Add a PRD to the backlog",
+	Long: `Add a PRD to the end of the backlog. List flags are repeatable.
+
+Examples:
+  ralph prd add "Rate limit the public API" \
+    -d "Reject clients over 100 req/min with 429" \
+    --criterion "Requests over the limit get 429" \
+    --step "Add limiter middleware" --step "Wire into router" \
+    --test "go test ./internal/api/..."`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, backlog, lock, err := lockWorkspaceBacklog()
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		p := prd.NewPRD(args[0])
+		prdAddFields.title = args[0]
+		prdAddFields.apply(cmd.Flags(), p)
+		if err := backlog.Add(p); err != nil {
+			return invalidPRD(err)
+		}
+		if err := backlog.Save(path); err != nil {
+			return err
+		}
+		display.New().Success(fmt.Sprintf("added %s", p.ID))
+		return nil
+	},
+}
+
+var prdListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List PRDs in the backlog",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, backlog, err := loadWorkspaceBacklog()
+		if err != nil {
+			return err
+		}
+
+		features := backlog.Features
+		if prdListStatus != "" {
+			status := types.Status(prdListStatus)
+			if !status.IsValid() {
+				return fmt.Errorf("invalid --status %q, must be one of: %v", prdListStatus, types.AllStatuses())
+			}
+			features = backlog.WithStatus(status)
+		}
+
+		out := cmd.OutOrStdout()
+		switch prdOutput {
+		case "json":
+			if features == nil {
+				features = []*prd.PRD{}
+			}
+			return printJSON(out, features)
+		case "table":
+			if len(features) == 0 {
+				fmt.Fprintln(out, "No PRDs")
+				return nil
+			}
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTATUS\tITER\tDEPENDS ON\tTITLE")
+			for _, p := range features {
+				fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n",
+					p.ID, p.Status, p.CurrentIteration, p.MaxIterations, strings.Join(p.DependsOn, ","), truncate(p.Title, 60))
+			}
+			return w.Flush()
+		}
+		return fmt.Errorf("invalid --output %q, must be table or json", prdOutput)
+	},
+}
+
+var prdShowCmd = &cobra.Command{
+	Use:   "show <prd-id>",
+	Short: "Show a PRD",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, backlog, err := loadWorkspaceBacklog()
+		if err != nil {
+			return err
+		}
+		p := backlog.Find(args[0])
+		if p == nil {
+			return fmt.Errorf("PRD %s not found in backlog", args[0])
+		}
+
+		out := cmd.OutOrStdout()
+		switch prdOutput {
+		case "json":
+			return printJSON(out, p)
+		case "table":
+			printPRD(out, p)
+			return nil
+		}
+		return fmt.Errorf("invalid --output %q, must be table or json", prdOutput)
+	},
+}
+
+var prdEditCmd = &cobra.Command{
+	Use:   "edit <prd-id>",
+	Short: "Edit a PRD with flags or $EDITOR",
+	Long: `Edit a PRD. With flags, the given fields are replaced; list flags replace
+the whole list. Without flags, the PRD opens as JSON in $EDITOR and is
+validated when the editor exits. The ID cannot be changed.
+
+Examples:
+  ralph prd edit auth-login-a1b2 --step "Add handler" --step "Add tests"
+  ralph prd edit auth-login-a1b2`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, backlog, lock, err := lockWorkspaceBacklog()
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+		p := backlog.Find(args[0])
+		if p == nil {
+			return fmt.Errorf("PRD %s not found in backlog", args[0])
+		}
+
+		var edited *prd.PRD
+		if localFlagsChanged(cmd) {
+			copied := *p
+			edited = &copied
+			prdEditFields.apply(cmd.Flags(), edited)
+			if err := backlog.Replace(edited); err != nil {
+				return invalidPRD(err)
+			}
+		} else {
+			if edited, err = editInEditor(backlog, p); err != nil {
+				return err
+			}
+			if edited == nil {
+				display.New().Info("Edit", "no changes")
+				return nil
+			}
+		}
+
+		edited.UpdatedAt = time.Now()
+		if err := backlog.Save(path); err != nil {
+			return err
+		}
+		display.New().Success(fmt.Sprintf("updated %s", edited.ID))
+		return nil
+	},
+}
+
+var prdRmCmd = &cobra.Command{
+	Use:   "rm <prd-id>",
+	Short: "Remove a PRD from the backlog",
+	Long: `Remove a PRD from the backlog. PRDs that other PRDs depend on are kept
+unless --force is given, which also drops those dependencies.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, backlog, lock, err := lockWorkspaceBacklog()
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		dependents := backlog.Dependents(args[0])
+		if len(dependents) > 0 && !prdRmForce {
+			return fmt.Errorf("%s is a dependency of %s; use --force to remove it anyway", args[0], strings.Join(dependents, ", "))
+		}
+		if err := backlog.Remove(args[0]); err != nil {
+			return err
+		}
+		for _, id := range dependents {
+			dependent := backlog.Find(id)
+			dependent.DependsOn = removeString(dependent.DependsOn, args[0])
+			dependent.UpdatedAt = time.Now()
+		}
+		if err := backlog.Save(path); err != nil {
+			return err
+		}
+		display.New().Success(fmt.Sprintf("removed %s", args[0]))
+		return nil
+	},
+}
+
+var prdReopenCmd = &cobra.Command{
+	Use:   "reopen <prd-id>",
+	Short: "Return a PRD to pending",
+	Long: `Return a complete, blocked or in-progress PRD to pending so it runs
+again. Unblock conditions are dropped, and a PRD that used all its
+iterations gets a fresh budget.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, backlog, lock, err := lockWorkspaceBacklog()
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+		p := backlog.Find(args[0])
+		if p == nil {
+			return fmt.Errorf("PRD %s not found in backlog", args[0])
+		}
+		if p.Status == types.StatusPending {
+			return fmt.Errorf("PRD %s is already pending", p.ID)
+		}
+
+		previous := p.Status
+		p.Reopen()
+		if err := backlog.Save(path); err != nil {
+			return err
+		}
+		display.New().Success(fmt.Sprintf("%s reopened (was %s)", p.ID, previous))
+		return nil
+	},
+}
+
+var prdImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import PRDs from a Markdown spec or an issue export",
+	Long: `Create PRDs from a Markdown spec or a JSON export of GitHub or Linear issues.
+
+Markdown: each heading starts a PRD (H1s when there are several, otherwise
+H2s). Checklist items ("- [ ] ...") become acceptance criteria, numbered
+items become steps, and other text becomes the description.
+
+Issues: a JSON array as produced by
+  gh issue list --json number,title,body,labels,state
+or a Linear export. Issue bodies are read like Markdown sections, labels
+become tags, and closed issues are skipped.
+
+"depends on #N" in the text becomes a dependency: the Nth PRD in a Markdown
+file, or issue #N (or ENG-N for Linear) in an export. References to anything
+outside the import are reported and dropped.
+
+Every PRD is validated; nothing is imported if any is invalid, unless
+--skip-invalid is given.
+
+Examples:
+  ralph prd import spec.md --dry-run
+  gh issue list --json number,title,body,labels,state > issues.json
+  ralph prd import issues.json --test "go test ./..."`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, backlog, lock, err := lockWorkspaceBacklog()
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+
+		format := importFormat
+		if format == "auto" {
+			format = "markdown"
+			if strings.EqualFold(filepath.Ext(args[0]), ".json") {
+				format = "issues"
+			}
+		}
+		var items []prd.Imported
+		switch format {
+		case "markdown":
+			items = prd.ParseMarkdown(data)
+		case "issues":
+			if items, err = prd.ParseIssues(data); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid --format %q, must be auto, markdown or issues", importFormat)
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("no PRDs found in %s", args[0])
+		}
+
+		d := display.New()
+		prds, unresolved := prd.ResolveImports(items)
+		for _, ref := range unresolved {
+			d.Warning("dependency outside the import dropped: " + ref)
+		}
+
+		for _, p := range prds {
+			p.Verification.Tests = importTests
+			p.Verification.Build = importBuilds
+		}
+		rejected := backlog.AddAll(prds)
+		if len(rejected) > 0 && !importSkipInvalid {
+			for _, p := range prds {
+				if err := rejected[p.ID]; err != nil {
+					d.Error(fmt.Sprintf("%q %v", p.Title, invalidPRD(err)))
+				}
+			}
+			return fmt.Errorf("%d of %d PRDs are invalid, nothing imported (use --skip-invalid to import the rest)", len(rejected), len(prds))
+		}
+		for _, p := range prds {
+			if err := rejected[p.ID]; err != nil {
+				d.Warning(fmt.Sprintf("skipped %q: %v", p.Title, err))
+				continue
+			}
+			d.Success(fmt.Sprintf("%-30s %s", p.ID, p.Title))
+		}
+		added, skipped := len(prds)-len(rejected), len(rejected)
+
+		if importDryRun {
+			d.Info("Dry run", fmt.Sprintf("would import %d PRDs, skip %d", added, skipped))
+			return nil
+		}
+		if err := backlog.Save(path); err != nil {
+			return err
+		}
+		d.Info("Imported", fmt.Sprintf("%d PRDs, skipped %d", added, skipped))
+		return nil
+	},
+}
+
+// apply copies the flags that were set onto p
+func (f *prdFields) apply(flags *pflag.FlagSet, p *prd.PRD) {
+	set := func(name string) bool { return flags.Changed(name) }
+	if f.title != "" {
+		p.Title = f.title
+	}
+	if set("description") {
+		p.Description = f.description
+	}
+	if set("criterion") {
+		p.AcceptanceCriteria = f.criteria
+	}
+	if set("step") {
+		p.Steps = f.steps
+	}
+	if set("depends-on") {
+		p.DependsOn = f.dependsOn
+	}
+	if set("related-file") {
+		p.RelatedFiles = f.relatedFiles
+	}
+	if set("tag") {
+		p.Tags = f.tags
+	}
+	if set("test") {
+		p.Verification.Tests = f.tests
+	}
+	if set("build") {
+		p.Verification.Build = f.builds
+	}
+	if set("type-check") {
+		p.Verification.TypeCheck = f.typeChecks
+	}
+	if set("custom") {
+		p.Verification.Custom = f.custom
+	}
+	if set("max-iterations") {
+		p.MaxIterations = f.maxIterations
+	}
+}
+
+// register adds the shared PRD field flags to cmd
+func (f *prdFields) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVarP(&f.description, "description", "d", "", "What the PRD accomplishes")
+	flags.StringArrayVar(&f.criteria, "criterion", nil, "Acceptance criterion (repeatable)")
+	flags.StringArrayVar(&f.steps, "step", nil, "Implementation step (repeatable)")
+	flags.StringArrayVar(&f.dependsOn, "depends-on", nil, "ID of a PRD that must complete first (repeatable)")
+	flags.StringArrayVar(&f.relatedFiles, "related-file", nil, "File the PRD touches (repeatable)")
+	flags.StringArrayVar(&f.tags, "tag", nil, "Tag for scoping learnings (repeatable)")
+	flags.StringArrayVar(&f.tests, "test", nil, "Test verification command (repeatable)")
+	flags.StringArrayVar(&f.builds, "build", nil, "Build verification command (repeatable)")
+	flags.StringArrayVar(&f.typeChecks, "type-check", nil, "Type-check verification command (repeatable)")
+	flags.StringArrayVar(&f.custom, "custom", nil, "Custom verification command (repeatable)")
+	flags.IntVar(&f.maxIterations, "max-iterations", prd.DefaultMaxIterations, "Iterations before escalation")
+}
+
+// editInEditor opens p as JSON in $EDITOR until it validates or the user
+// gives up. Returns nil when nothing changed.
+func editInEditor(backlog *prd.Backlog, p *prd.PRD) (*prd.PRD, error) {
+	original, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal PRD: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "ralph-prd-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, p.ID+".json")
+	if err := os.WriteFile(file, original, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+
+	d := display.New()
+	for {
+		if err := runEditor(file); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if string(data) == string(original) {
+			return nil, nil
+		}
+
+		edited := &prd.PRD{}
+		err = json.Unmarshal(data, edited)
+		switch {
+		case err != nil:
+			err = fmt.Errorf("invalid JSON: %w", err)
+		case edited.ID != p.ID:
+			err = fmt.Errorf("the ID cannot be changed (was %s)", p.ID)
+		default:
+			err = backlog.Replace(edited)
+		}
+		if err == nil {
+			return edited, nil
+		}
+
+		d.Error(err.Error())
+		if !confirm("Edit again?") {
+			return nil, errors.New("edit aborted, backlog unchanged")
+		}
+	}
+}
+
+// runEditor opens file in $VISUAL or $EDITOR, falling back to vi
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	return nil
+}
+
+// localFlagsChanged reports whether any of cmd's own flags were given
+func localFlagsChanged(cmd *cobra.Command) bool {
+	changed := false
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		changed = changed || f.Changed
+	})
+	return changed
+}
+
+// confirm asks a yes/no question on stdin, defaulting to yes. A closed
+// stdin counts as no.
+func confirm(question string) bool {
+	fmt.Printf("%s [Y/n] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// lockWorkspaceBacklog takes the workspace lock and loads the backlog. The
+// caller releases the lock once its changes are saved.
+func lockWorkspaceBacklog() (string, *prd.Backlog, *workspace.Lock, error) {
+	workspaceDir, err := workspace.Find()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	lock, err := workspace.AcquireLock(workspaceDir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	path := workspace.PRDPath(workspaceDir)
+	backlog, err := prd.LoadBacklog(path)
+	if err != nil {
+		lock.Release()
+		return "", nil, nil, err
+	}
+	return path, backlog, lock, nil
+}
+
+func loadWorkspaceBacklog() (string, *prd.Backlog, error) {
+	workspaceDir, err := workspace.Find()
+	if err != nil {
+		return "", nil, err
+	}
+	path := workspace.PRDPath(workspaceDir)
+	backlog, err := prd.LoadBacklog(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, backlog, nil
+}
+
+// invalidPRD formats validation errors for the terminal
+func invalidPRD(err error) error {
+	var errs *types.ValidationErrors
+	if errors.As(err, &errs) {
+		return fmt.Errorf("invalid PRD:\n%s", errs.ToPrompt())
+	}
+	return err
+}
+
+func printPRD(out io.Writer, p *prd.PRD) {
+	fmt.Fprintf(out, "%s  %s\n", p.ID, p.Title)
+	fmt.Fprintf(out, "Status: %s  Iteration: %d/%d\n", p.Status, p.CurrentIteration, p.MaxIterations)
+	if p.Parent != "" {
+		fmt.Fprintf(out, "Parent: %s\n", p.Parent)
+	}
+	if len(p.DependsOn) > 0 {
+		fmt.Fprintf(out, "Depends on: %s\n", strings.Join(p.DependsOn, ", "))
+	}
+	if p.Description != "" {
+		fmt.Fprintf(out, "\n%s\n", p.Description)
+	}
+	printList(out, "Acceptance criteria", p.AcceptanceCriteria)
+	printList(out, "Steps", p.Steps)
+	printList(out, "Related files", p.RelatedFiles)
+	printList(out, "Tests", p.Verification.Tests)
+	printList(out, "Build", p.Verification.Build)
+	printList(out, "Type check", p.Verification.TypeCheck)
+	printList(out, "Custom checks", p.Verification.Custom)
+	for _, c := range p.UnblockWhen {
+		fmt.Fprintf(out, "Unblock when: %s\n", c)
+	}
+	if attempt := p.LastAttempt(); attempt != nil {
+		fmt.Fprintf(out, "\nLast attempt: iteration %d, %s\n", attempt.Iteration, attempt.Outcome)
+		if attempt.Blocker != "" {
+			fmt.Fprintf(out, "Blocker: %s\n", attempt.Blocker)
+		}
+	}
+}
+
+func printList(out io.Writer, heading string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\n%s:\n", heading)
+	for _, item := range items {
+		fmt.Fprintf(out, "  - %s\n", item)
+	}
+}
+
+func printJSON(out io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Fprintln(out, string(data))
+	return nil
+}
+
+func removeString(list []string, s string) []string {
+	var result []string
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func init() {
+	rootCmd.AddCommand(prdCmd)
+	prdCmd.AddCommand(prdAddCmd, prdListCmd, prdShowCmd, prdEditCmd, prdRmCmd, prdReopenCmd, prdImportCmd)
+
+	prdAddFields.register(prdAddCmd)
+	prdEditFields.register(prdEditCmd)
+	prdEditCmd.Flags().StringVar(&prdEditFields.title, "title", "", "New title")
+
+	prdListCmd.Flags().StringVar(&prdListStatus, "status", "", "Only list PRDs with this status")
+	for _, cmd := range []*cobra.Command{prdListCmd, prdShowCmd} {
+		cmd.Flags().StringVarP(&prdOutput, "output", "o", "table", "Output format: table or json")
+	}
+	prdRmCmd.Flags().BoolVar(&prdRmForce, "force", false, "Remove even if other PRDs depend on it")
+
+	prdImportCmd.Flags().StringVar(&importFormat, "format", "auto", "Input format: auto, markdown or issues")
+	prdImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without saving")
+	prdImportCmd.Flags().BoolVar(&importSkipInvalid, "skip-invalid", false, "Import the valid PRDs and skip the rest")
+	prdImportCmd.Flags().StringArrayVar(&importTests, "test", nil, "Test verification command for every imported PRD (repeatable)")
+	prdImportCmd.Flags().StringArrayVar(&importBuilds, "build", nil, "Build verification command for every imported PRD (repeatable)")
+}
@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/runner"
+	"github.com/daydemir/ralph/internal/utils"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compactKeep      int
+	compactOlderThan time.Duration
+	compactSummarize bool
+	compactDryRun    bool
+	compactModel     string
+)
+
+var (
+	queryPRD      string
+	queryKinds    []string
+	queryCategory string
+	querySince    string
+	queryUntil    string
+	queryArchived bool
+	queryOutput   string
+)
+
+var progressCmd = &cobra.Command{
+	Use:   "progress",
+	Short: "Inspect and maintain .ralph/progress.json",
+}
+
+var progressCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Archive old progress entries and merge duplicates",
+	Long: `Shrink .ralph/progress.json so it stays within the prompt budget.
+
+Entries beyond the most recent --keep are moved to a timestamped file in
+.ralph/progress-archive/ (optionally only those older than --older-than).
+Duplicate patterns and learnings are merged into their first occurrence.
+Learnings and patterns themselves are never archived.
+
+With --summarize, Claude condenses the full history into a short "state of
+the codebase" section that is inlined into every build prompt.
+
+Examples:
+  ralph progress compact --dry-run
+  ralph progress compact --keep 20 --older-than 720h --summarize`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		lock, err := workspace.AcquireLock(workspaceDir)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		cfg, err := config.Load(workspaceDir)
+		if err != nil {
+			return err
+		}
+
+		path := workspace.ProgressPath(workspaceDir)
+		before, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		progress, err := prd.LoadProgress(path)
+		if err != nil {
+			return err
+		}
+
+		opts := prd.CompactOptions{Keep: compactKeep}
+		if compactOlderThan > 0 {
+			opts.Before = time.Now().Add(-compactOlderThan)
+		}
+		result := progress.Compact(opts)
+
+		d := display.New()
+		if compactSummarize {
+			r := runner.New(workspaceDir, cfg, d, compactModel)
+			state, err := r.SummarizeProgress(cmd.Context(), progress, result.Archived)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			progress.CodebaseState = state
+			progress.CodebaseStateAt = &now
+		}
+
+		if compactDryRun {
+			after, err := json.MarshalIndent(progress, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal progress: %w", err)
+			}
+			diff, err := utils.UnifiedDiff("progress.json", before, after)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), diff)
+			d.Info("Dry run", compactSummary(result, compactSummarize))
+			return nil
+		}
+
+		if !result.Changed() && !compactSummarize {
+			d.Info("Progress", "nothing to compact")
+			return nil
+		}
+		if len(result.Archived) > 0 {
+			archivePath, err := prd.WriteArchive(workspace.ProgressArchiveDir(workspaceDir), result.Archived)
+			if err != nil {
+				return err
+			}
+			d.Info("Archive", archivePath)
+		}
+		if err := progress.Save(path); err != nil {
+			return err
+		}
+		d.Success(compactSummary(result, compactSummarize))
+		return nil
+	},
+}
+
+var progressQueryCmd = &cobra.Command{
+	Use:   "query [text]",
+	Short: "Search progress entries, observations, learnings and patterns",
+	Long: `Search .ralph/progress.json. All filters combine; the optional text
+argument is a case-insensitive search over each record's text, details and
+file scope.
+
+--category matches an observation category (bug, api-issue, ...), a
+learning or pattern type, or an entry status. --since and --until take a
+date (2006-01-02), an RFC 3339 timestamp, or an age such as 7d or 36h.
+
+Examples:
+  ralph progress query auth --kind learning
+  ralph progress query --kind observation --category bug --since 7d
+  ralph progress query --prd auth-login-a1b2 -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		q := prd.Query{Kinds: queryKinds, PRDID: queryPRD, Category: queryCategory}
+		if len(args) == 1 {
+			q.Text = args[0]
+		}
+		for _, kind := range queryKinds {
+			if !slices.Contains(prd.QueryKinds, kind) {
+				return fmt.Errorf("invalid --kind %q, must be one of: %v", kind, prd.QueryKinds)
+			}
+		}
+		if q.Since, err = parseTimeFlag(querySince); err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		if q.Until, err = parseTimeFlag(queryUntil); err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+
+		progress, err := prd.LoadOrNewProgress(workspace.ProgressPath(workspaceDir))
+		if err != nil {
+			return err
+		}
+		results := progress.Query(q)
+		if queryArchived {
+			archived, err := prd.LoadArchivedEntries(workspace.ProgressArchiveDir(workspaceDir))
+			if err != nil {
+				return err
+			}
+			results = append(prd.QueryEntries(archived, q), results...)
+			slices.SortStableFunc(results, func(a, b prd.QueryResult) int {
+				return a.Timestamp.Compare(b.Timestamp)
+			})
+		}
+
+		out := cmd.OutOrStdout()
+		switch queryOutput {
+		case "json":
+			if results == nil {
+				results = []prd.QueryResult{}
+			}
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal results: %w", err)
+			}
+			fmt.Fprintln(out, string(data))
+		case "table":
+			if len(results) == 0 {
+				fmt.Fprintln(out, "No matching records")
+				return nil
+			}
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "DATE\tKIND\tID\tPRD\tCATEGORY\tTEXT")
+			for _, r := range results {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					r.Timestamp.Local().Format("2006-01-02 15:04"), r.Kind, r.ID, r.PRDID, r.Category, truncate(r.Text, 80))
+			}
+			return w.Flush()
+		default:
+			return fmt.Errorf("invalid --output %q, must be table or json", queryOutput)
+		}
+		return nil
+	},
+}
+
+// parseTimeFlag accepts a date, an RFC 3339 timestamp, or an age like 7d or 36h
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-age), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, timestamp or age", value)
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+func compactSummary(result *prd.CompactResult, summarized bool) string {
+	msg := fmt.Sprintf("archived %d entries, merged %d duplicate patterns and %d duplicate learnings",
+		len(result.Archived), result.MergedPatterns, result.MergedLearnings)
+	if summarized {
+		msg += ", updated codebase state"
+	}
+	return msg
+}
+
+func init() {
+	rootCmd.AddCommand(progressCmd)
+	progressCmd.AddCommand(progressCompactCmd)
+	progressCmd.AddCommand(progressQueryCmd)
+
+	progressCompactCmd.Flags().IntVar(&compactKeep, "keep", 50, "Number of most recent entries to keep")
+	progressCompactCmd.Flags().DurationVar(&compactOlderThan, "older-than", 0, "Only archive entries older than this (e.g. 720h)")
+	progressCompactCmd.Flags().BoolVar(&compactSummarize, "summarize", false, "Summarize the history into a codebase state section with Claude")
+	progressCompactCmd.Flags().BoolVar(&compactDryRun, "dry-run", false, "Show the changes as a diff without writing anything")
+	progressCompactCmd.Flags().StringVarP(&compactModel, "model", "m", "", "Model to use for --summarize (sonnet, opus, haiku)")
+
+	progressQueryCmd.Flags().StringVar(&queryPRD, "prd", "", "Only records from this PRD")
+	progressQueryCmd.Flags().StringSliceVar(&queryKinds, "kind", nil, "Record kinds: entry, observation, learning, pattern (repeatable)")
+	progressQueryCmd.Flags().StringVar(&queryCategory, "category", "", "Observation category, learning/pattern type or entry status")
+	progressQueryCmd.Flags().StringVar(&querySince, "since", "", "Only records at or after this date or age (e.g. 2025-01-15, 7d)")
+	progressQueryCmd.Flags().StringVar(&queryUntil, "until", "", "Only records before this date or age")
+	progressQueryCmd.Flags().BoolVar(&queryArchived, "archived", false, "Also search entries archived by 'ralph progress compact'")
+	progressQueryCmd.Flags().StringVarP(&queryOutput, "output", "o", "table", "Output format: table or json")
+}
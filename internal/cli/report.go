@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/daydemir/ralph/internal/logs"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/report"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat string
+	reportSince  string
+	reportOutput string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize the backlog and recent activity",
+	Long: `Write a report of the backlog by status, each PRD's attempts with their
+outcomes, durations, tokens and cost, current blockers, completions and
+learnings, and the Claude sessions in the period.
+
+The report is Markdown by default, or a self-contained HTML page with
+--format html. --since limits activity to a date (2006-01-02), an RFC 3339
+timestamp or an age like 7d or 48h; the backlog itself is always shown in
+full.
+
+Examples:
+  ralph report --since 7d
+  ralph report --format html --since 7d -o report.html`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reportFormat != "markdown" && reportFormat != "html" {
+			return fmt.Errorf("unknown format %q (use markdown or html)", reportFormat)
+		}
+		since, err := parseTimeFlag(reportSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+		backlog, err := prd.LoadBacklog(workspace.PRDPath(workspaceDir))
+		if err != nil {
+			return err
+		}
+		progress, err := prd.LoadOrNewProgress(workspace.ProgressPath(workspaceDir))
+		if err != nil {
+			return err
+		}
+
+		project := progress.ProjectName
+		if project == "" {
+			project = filepath.Base(workspaceDir)
+		}
+		r := report.Build(report.Input{
+			Project:  project,
+			Since:    since,
+			Backlog:  backlog,
+			Progress: progress,
+			Sessions: claudeSessions(workspaceDir),
+		})
+
+		var out string
+		if reportFormat == "html" {
+			out, err = r.HTML()
+		} else {
+			out, err = r.Markdown()
+		}
+		if err != nil {
+			return err
+		}
+
+		if reportOutput == "" {
+			fmt.Print(out)
+			return nil
+		}
+		if err := os.WriteFile(reportOutput, []byte(out), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", reportOutput, err)
+		}
+		fmt.Printf("Wrote %s\n", reportOutput)
+		return nil
+	},
+}
+
+// claudeSessions lists the workspace's Claude sessions, or nil when Claude
+// has no logs for it
+func claudeSessions(workspaceDir string) []logs.SessionInfo {
+	extractor, err := logs.NewVerbatimExtractor(workspaceDir, filepath.Join(workspaceDir, ".planning"))
+	if err != nil {
+		return nil
+	}
+	sessions, err := extractor.GetSessions()
+	if err != nil {
+		return nil
+	}
+	return sessions
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringVarP(&reportFormat, "format", "f", "markdown", "Output format (markdown, html)")
+	reportCmd.Flags().StringVar(&reportSince, "since", "", "Only include activity since a date, timestamp or age (e.g. 7d)")
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "Write the report to a file instead of stdout")
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/runner"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var reviewModel string
+
+var reviewCmd = &cobra.Command{
+	Use:   "review [prd-id]",
+	Short: "Verify PRDs awaiting review",
+	Long: `Run the two-factor review for PRDs in pending_review status.
+
+For each PRD the verification commands are run independently, then a
+reviewer session checks every acceptance criterion against the diff.
+PRDs that pass are marked complete; the rest return to in_progress
+with the reviewer's findings recorded on the attempt.
+
+Without an argument, every pending_review PRD is reviewed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		lock, err := workspace.AcquireLock(workspaceDir)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		cfg, err := config.Load(workspaceDir)
+		if err != nil {
+			return err
+		}
+
+		r := runner.New(workspaceDir, cfg, display.New(), reviewModel)
+
+		if len(args) == 1 {
+			return r.ReviewPRD(cmd.Context(), args[0])
+		}
+		if err := r.ReviewPending(cmd.Context()); err != nil {
+			return fmt.Errorf("review failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+
+	reviewCmd.Flags().StringVarP(&reviewModel, "model", "m", "", "Model to use for the reviewer (sonnet, opus, haiku)")
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -20,8 +21,19 @@ var rootCmd = &cobra.Command{
 
 Core Commands:
   discuss [context]   Plan, review, update - Ralph determines context
+  plan [context]      Plan PRDs in an interactive Claude session
   run                 Execute the next incomplete plan
   run --loop [N]      Autonomous execution (up to N plans)
+  run --parallel N    Run up to N independent PRDs at once
+  verify <prd-id>     Run a PRD's verification commands
+  prd <command>       Add, import, list, show, edit, rm or reopen PRDs
+  review [prd-id]     Verify PRDs awaiting review
+  unblock <prd-id>    Unblock a PRD now or when conditions hold
+  split <prd-id>      Break a PRD into smaller child PRDs
+  schema <file>       Print the JSON Schema for prd, progress, context or config
+  progress compact    Archive old progress entries and merge duplicates
+  progress query      Search progress history
+  report              Summarize the backlog and recent activity
   status              Show current position and progress
   status -v           Show all phases and plans
 
@@ -41,7 +53,10 @@ Workflow:
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer trapSignals(cancel)()
+	return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/runner"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	runLoop     int
+	runModel    string
+	runForce    bool
+	runWorktree bool
+	runParallel int
+	runRecover  string
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Execute the next PRD from the backlog",
+	Long: `Execute the next runnable PRD from .ralph/prd.json.
+
+Each iteration runs Claude with a fresh context on a single PRD. When the
+executor claims completion the PRD moves to pending_review, its verification
+commands are run independently, and a reviewer session checks every
+acceptance criterion against the diff. Only then is it marked complete.
+
+In a git repository each PRD runs on its own ralph/<prd-id> branch, created
+from the branch you started on, and Ralph commits after every iteration. Once
+a PRD passes review its branch is merged back into that branch; a conflict is
+reported and the PRD's branch is left checked out for a manual merge. Ralph
+refuses to start on a working tree with uncommitted changes outside .ralph/
+unless --force is given.
+
+With --worktree (or build.worktrees in config.yaml) each PRD runs in its own
+git worktree under .ralph/worktrees/<prd-id>, leaving your checkout alone.
+Once a PRD passes review its branch is fast-forwarded or rebased onto your
+current branch and the worktree is removed; conflicts are reported and the
+worktree is kept for a manual merge.
+
+With --parallel N up to N PRDs run at once, each in its own worktree, with
+their output prefixed by the PRD ID. Only PRDs whose dependencies are
+complete and merged are started, and completed branches are merged in
+dependency order. A PRD that fails is not retried; the others carry on.
+
+If an earlier run was killed mid-iteration (a closed laptop, a dropped SSH
+session), its PRD is found on startup along with what the iteration left in
+git and its run directory. Ralph asks whether to resume it, reset it to
+pending or block it; --recover answers up front, and without a terminal
+Ralph resumes.
+
+Ctrl-C stops gracefully: Claude finishes its current tool call, the attempt
+and progress are recorded and the changes committed, and no new iteration
+starts. A second Ctrl-C stops Claude and everything it started right away.
+Processes Claude leaves running, like dev servers and watchers, are stopped
+when its session ends: SIGTERM first, then SIGKILL after a few seconds.
+
+Examples:
+  ralph run              # Run one iteration
+  ralph run --loop       # Loop up to build.default_loop_iterations
+  ralph run --loop 5     # Loop up to 5 iterations
+  ralph run --parallel 3 # Run up to 3 PRDs at once`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		lock, err := workspace.AcquireLock(workspaceDir)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		cfg, err := config.Load(workspaceDir)
+		if err != nil {
+			return err
+		}
+
+		if runWorktree {
+			cfg.Build.Worktrees = true
+		}
+
+		action := types.InterruptAction(runRecover)
+		if !action.IsValid() {
+			return fmt.Errorf("invalid --recover %q, expected one of %v", runRecover, types.AllInterruptActions())
+		}
+
+		d := display.New()
+		r := runner.New(workspaceDir, cfg, d, runModel)
+		r.SetForce(runForce)
+
+		if err := recoverInterrupted(d, r, action); err != nil {
+			return err
+		}
+		onInterrupt(r.Stop)
+		defer onInterrupt(nil)
+
+		max := runLoop
+		if max < 0 {
+			max = cfg.Build.DefaultLoopIterations
+		}
+		switch {
+		case runParallel > 0:
+			if max == 0 {
+				max = cfg.Build.DefaultLoopIterations
+			}
+			err = r.RunParallel(cmd.Context(), runParallel, max)
+		case max != 0:
+			err = r.Loop(cmd.Context(), max)
+		default:
+			err = runOnce(cmd.Context(), d, r)
+		}
+		// A second Ctrl-C cancels whatever the stopping run was still doing
+		if errors.Is(err, context.Canceled) && r.Stopped() {
+			d.Warning("stopped; run 'ralph run' to continue")
+			return nil
+		}
+		return err
+	},
+}
+
+// runOnce runs a single iteration of the next runnable PRD
+func runOnce(ctx context.Context, d *display.Display, r *runner.Runner) error {
+	result, err := r.RunNext(ctx)
+	if errors.Is(err, runner.ErrNothingToRun) {
+		d.AllComplete()
+		return nil
+	}
+	if errors.Is(err, runner.ErrHumanReview) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.Info("Result", fmt.Sprintf("%s %s (status: %s)", result.PRDID, result.Outcome, result.Status))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().IntVarP(&runLoop, "loop", "l", 0, "Run autonomously for up to N iterations")
+	runCmd.Flags().Lookup("loop").NoOptDefVal = "-1"
+	runCmd.Flags().StringVarP(&runModel, "model", "m", "", "Model to use (sonnet, opus, haiku)")
+	runCmd.Flags().BoolVar(&runForce, "force", false, "Run even if the working tree has uncommitted changes")
+	runCmd.Flags().BoolVar(&runWorktree, "worktree", false, "Run each PRD in its own git worktree under .ralph/worktrees/")
+	runCmd.Flags().IntVarP(&runParallel, "parallel", "p", 0, "Run up to N independent PRDs at once in separate worktrees")
+	runCmd.Flags().StringVar(&runRecover, "recover", "", "What to do with iterations a killed run left unfinished (resume, reset, block)")
+}
+
+// recoverInterrupted closes iterations that a killed Ralph process left
+// unfinished, asking what to do with each unless action is set
+func recoverInterrupted(d *display.Display, r *runner.Runner, action types.InterruptAction) error {
+	interrupted, err := r.Interrupted()
+	if err != nil {
+		return err
+	}
+
+	for _, i := range interrupted {
+		d.Warning(fmt.Sprintf("%s (%s) was interrupted", i.PRDID, i.Title))
+		d.Info("Found", i.Summary())
+
+		chosen := action
+		if chosen == "" {
+			chosen = askInterruptAction()
+		}
+		if err := r.Recover(i.PRDID, chosen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// askInterruptAction asks what to do with an interrupted iteration,
+// resuming when there is no terminal to ask on
+func askInterruptAction() types.InterruptAction {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return types.InterruptResume
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("[r]esume, re[s]et to pending or [b]lock? [R/s/b] ")
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+			return types.InterruptResume
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "r", "resume":
+			return types.InterruptResume
+		case "s", "reset":
+			return types.InterruptReset
+		case "b", "block":
+			return types.InterruptBlock
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/daydemir/ralph/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema <prd|progress|context|config>",
+	Short: "Print the JSON Schema for a Ralph file",
+	Long: `Print a JSON Schema (draft 2020-12) for one of Ralph's files.
+
+The schema is derived from the Go types Ralph uses to read the file, with
+status enums and required fields matching Ralph's own validation.
+
+Examples:
+  ralph schema prd > .ralph/prd.schema.json
+  ralph schema config    # for yaml-language-server`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: schema.Names(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := schema.For(args[0])
+		if err != nil {
+			return err
+		}
+
+		data, err := s.JSON()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/daydemir/ralph/internal/display"
+)
+
+// interruptExitCode is the conventional exit status after SIGINT
+const interruptExitCode = 130
+
+var (
+	stopMu      sync.Mutex
+	stopHandler func()
+	passThrough bool // Ctrl-C belongs to an interactive child program
+)
+
+// onInterrupt registers fn to run on the first Ctrl-C instead of cancelling
+// the command, so it can wind down cleanly. A second Ctrl-C still cancels.
+func onInterrupt(fn func()) {
+	stopMu.Lock()
+	defer stopMu.Unlock()
+	stopHandler = fn
+}
+
+// passInterrupts leaves Ctrl-C to an interactive Claude session, which
+// shares the terminal and gets the SIGINT itself, until the returned
+// function is called. SIGTERM is still handled as usual.
+func passInterrupts() func() {
+	stopMu.Lock()
+	defer stopMu.Unlock()
+	passThrough = true
+	return func() {
+		stopMu.Lock()
+		defer stopMu.Unlock()
+		passThrough = false
+	}
+}
+
+// trapSignals handles SIGINT and SIGTERM for the running command. The first
+// signal calls the handler registered with onInterrupt, or cancels the
+// command when there is none; the next cancels the command, killing any
+// Claude session; after that Ralph exits immediately. The returned function
+// stops the trap.
+func trapSignals(cancel context.CancelFunc) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		d := display.New()
+		presses := 0
+		for {
+			var sig os.Signal
+			select {
+			case <-done:
+				return
+			case sig = <-signals:
+			}
+
+			stopMu.Lock()
+			handler, pass := stopHandler, passThrough
+			stopMu.Unlock()
+			if pass && sig == os.Interrupt {
+				continue
+			}
+			presses++
+
+			switch {
+			case presses == 1 && handler != nil:
+				d.Warning("stopping after Claude's current tool call; press Ctrl-C again to kill it now")
+				handler()
+			case presses == 1 || (presses == 2 && handler != nil):
+				d.Warning("interrupted; cleaning up, press Ctrl-C again to exit immediately")
+				cancel()
+			default:
+				os.Exit(interruptExitCode)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
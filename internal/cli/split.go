@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/runner"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var splitModel string
+
+var splitCmd = &cobra.Command{
+	Use:   "split <prd-id>",
+	Short: "Break a PRD into smaller child PRDs",
+	Long: `Run a planning session that breaks a PRD into 2-5 smaller PRDs.
+
+The children are inserted after the parent and run in order: the first
+waits on the parent's dependencies, each later one on the child before it.
+They inherit the parent's verification commands, and its related files and
+tags when the planner names none, and link back to it via "parent".
+
+The parent is blocked until every child is complete, then goes to review
+so its own acceptance criteria get a final check against the children's
+work. It only runs again if that review fails.
+
+Ralph also splits a PRD automatically after build.split_after_bailouts
+consecutive bailouts, and when it runs out of iterations under the split
+escalation policy.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		lock, err := workspace.AcquireLock(workspaceDir)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		cfg, err := config.Load(workspaceDir)
+		if err != nil {
+			return err
+		}
+
+		d := display.New()
+		r := runner.New(workspaceDir, cfg, d, splitModel)
+
+		children, err := r.Split(cmd.Context(), args[0])
+		if err != nil {
+			return fmt.Errorf("split failed: %w", err)
+		}
+		d.Success(fmt.Sprintf("%s split into %d PRDs", args[0], len(children)))
+		for _, id := range children {
+			d.Info("Child", id)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().StringVarP(&splitModel, "model", "m", "", "Model to use for the planner (sonnet, opus, haiku)")
+}
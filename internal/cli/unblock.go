@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	unblockCommands []string
+	unblockFiles    []string
+	unblockPRDs     []string
+)
+
+var unblockCmd = &cobra.Command{
+	Use:   "unblock <prd-id>",
+	Short: "Unblock a PRD now or when conditions hold",
+	Long: `Return a blocked PRD to pending, or attach conditions under which
+Ralph returns it automatically.
+
+Without flags the PRD is unblocked immediately. A PRD that was blocked for
+running out of iterations gets a fresh budget. With flags the conditions
+replace any the PRD already has; all of them must hold. Ralph evaluates
+them before selecting each PRD to run.
+
+Examples:
+  ralph unblock auth-login-a1b2
+  ralph unblock auth-login-a1b2 --when-file .env
+  ralph unblock auth-login-a1b2 --when-command "pg_isready -h localhost"
+  ralph unblock auth-login-a1b2 --when-prd db-schema-c3d4`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		lock, err := workspace.AcquireLock(workspaceDir)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		path := workspace.PRDPath(workspaceDir)
+		backlog, err := prd.LoadBacklog(path)
+		if err != nil {
+			return err
+		}
+		p := backlog.Find(args[0])
+		if p == nil {
+			return fmt.Errorf("PRD %s not found in backlog", args[0])
+		}
+		if p.Status != types.StatusBlocked {
+			return fmt.Errorf("PRD %s is %s, not blocked", p.ID, p.Status)
+		}
+
+		var conditions []prd.UnblockCondition
+		for _, c := range unblockCommands {
+			conditions = append(conditions, prd.UnblockCondition{Command: c})
+		}
+		for _, f := range unblockFiles {
+			conditions = append(conditions, prd.UnblockCondition{FileExists: f})
+		}
+		for _, id := range unblockPRDs {
+			if backlog.Find(id) == nil {
+				return fmt.Errorf("PRD %s not found in backlog", id)
+			}
+			conditions = append(conditions, prd.UnblockCondition{PRDComplete: id})
+		}
+
+		d := display.New()
+		if len(conditions) == 0 {
+			if attempt := p.LastAttempt(); attempt != nil {
+				attempt.Observations = append(attempt.Observations, "unblocked: manually")
+			}
+			p.Reopen()
+			if err := backlog.Save(path); err != nil {
+				return err
+			}
+			d.Success(fmt.Sprintf("%s returned to pending", p.ID))
+			return nil
+		}
+
+		p.UnblockWhen = conditions
+		if err := backlog.Save(path); err != nil {
+			return err
+		}
+		for _, c := range conditions {
+			d.Info("Unblock when", c.String())
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unblockCmd)
+
+	unblockCmd.Flags().StringArrayVar(&unblockCommands, "when-command", nil, "Shell command that must exit 0 (repeatable)")
+	unblockCmd.Flags().StringArrayVar(&unblockFiles, "when-file", nil, "Path that must exist, relative to the workspace (repeatable)")
+	unblockCmd.Flags().StringArrayVar(&unblockPRDs, "when-prd", nil, "PRD that must be complete (repeatable)")
+}
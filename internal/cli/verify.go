@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/runner"
+	"github.com/daydemir/ralph/internal/verify"
+	"github.com/daydemir/ralph/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <prd-id>",
+	Short: "Run a PRD's verification commands",
+	Long: `Run the build, type_check, tests and custom verification commands
+defined on a PRD, in that order, from the workspace root.
+
+Each command is bounded by verify.timeout from .ralph/config.yaml.
+Stdout and stderr are saved under .ralph/runs/<prd-id>-<iteration>/verify/
+together with a report.json summarizing the results. If a baseline was
+taken when the iteration started, only new failures are reported as errors.
+
+Failing commands are rerun verify.flaky_reruns times; failures that pass
+on rerun in the same run are ignored and recorded in .ralph/flaky.json.
+A failure that persists across reruns always fails, even for a test
+recorded as flaky before.
+
+This does not change the PRD's status; use 'ralph review' for that.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceDir, err := workspace.Find()
+		if err != nil {
+			return err
+		}
+
+		lock, err := workspace.AcquireLock(workspaceDir)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+
+		cfg, err := config.Load(workspaceDir)
+		if err != nil {
+			return err
+		}
+
+		backlog, err := prd.LoadBacklog(workspace.PRDPath(workspaceDir))
+		if err != nil {
+			return err
+		}
+		p := backlog.Find(args[0])
+		if p == nil {
+			return fmt.Errorf("PRD %s not found in backlog", args[0])
+		}
+
+		d := display.New()
+		r := runner.New(workspaceDir, cfg, d, "")
+
+		runDir := workspace.RunDir(workspaceDir, p.ID, p.CurrentIteration)
+		verifier, err := r.Verifier(p.ID, filepath.Join(runDir, verify.ResultsDir))
+		if err != nil {
+			return err
+		}
+		report, err := verifier.Run(cmd.Context(), p)
+		if err != nil {
+			return err
+		}
+		if err := r.SaveFlaky(verifier); err != nil {
+			return err
+		}
+
+		for _, res := range report.Results {
+			line := fmt.Sprintf("[%s] %s (%s)", res.Kind, res.Command, res.Duration.Round(time.Millisecond))
+			if res.Passed {
+				d.Success(line)
+			} else {
+				d.Error(line)
+			}
+			for _, name := range res.Flaky {
+				d.Warning("flaky, ignored: " + name)
+			}
+		}
+		d.Info("Report", report.Path)
+
+		// Compare against the baseline taken when the current iteration started
+		if baseline, err := verify.LoadReport(filepath.Join(runDir, verify.BaselineDir, verify.ReportFile)); err == nil {
+			comparison := verify.Compare(baseline, report)
+			d.Info("Baseline", comparison.Summary())
+			for _, name := range comparison.NewFailures {
+				d.Error("new failure: " + name)
+			}
+			if !comparison.HasRegressions() {
+				return nil
+			}
+			return fmt.Errorf("%s", comparison.Summary())
+		}
+
+		if !report.Passed {
+			return fmt.Errorf("%s", report.Summary())
+		}
+		d.Success(report.Summary())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
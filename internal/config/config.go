@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/daydemir/ralph/internal/types"
 	"github.com/spf13/viper"
 )
 
@@ -13,6 +15,7 @@ type Config struct {
 	LLM    LLMConfig    `mapstructure:"llm"`
 	Claude ClaudeConfig `mapstructure:"claude"`
 	Build  BuildConfig  `mapstructure:"build"`
+	Verify VerifyConfig `mapstructure:"verify"`
 }
 
 // LLMConfig contains LLM backend settings
@@ -30,6 +33,24 @@ type ClaudeConfig struct {
 // BuildConfig contains build/execution settings
 type BuildConfig struct {
 	DefaultLoopIterations int `mapstructure:"default_loop_iterations"`
+	RepairAttempts        int `mapstructure:"repair_attempts"`  // Claude sessions to fix invalid state files; -1 disables
+	LearningsLimit        int `mapstructure:"learnings_limit"`  // learnings inlined into the build prompt; -1 disables
+	LearningsBudget       int `mapstructure:"learnings_budget"` // max bytes of learnings inlined into the build prompt
+
+	Escalation      types.EscalationPolicy `mapstructure:"escalation"`       // what to do when a PRD hits max_iterations
+	EscalationModel string                 `mapstructure:"escalation_model"` // model used by the stronger_model policy
+
+	SplitAfterBailouts int `mapstructure:"split_after_bailouts"` // consecutive bailouts that split a PRD automatically; -1 disables
+
+	Rollback  types.RollbackPolicy `mapstructure:"rollback"`  // what to do with the working tree after a failed iteration
+	Worktrees bool                 `mapstructure:"worktrees"` // run each PRD in its own git worktree under .ralph/worktrees/
+}
+
+// VerifyConfig contains settings for running PRD verification commands
+type VerifyConfig struct {
+	Timeout      time.Duration `mapstructure:"timeout"`       // per command, e.g. "10m"
+	SkipBaseline bool          `mapstructure:"skip_baseline"` // don't snapshot results before each iteration
+	FlakyReruns  int           `mapstructure:"flaky_reruns"`  // reruns of a failing command to detect flakes; -1 disables
 }
 
 // Load reads the config from the workspace
@@ -56,6 +77,13 @@ func Load(workspaceDir string) (*Config, error) {
 	// Apply defaults for missing values
 	applyDefaults(&cfg)
 
+	if !cfg.Build.Escalation.IsValid() {
+		return nil, fmt.Errorf("invalid build.escalation %q, must be one of: %v", cfg.Build.Escalation, types.AllEscalationPolicies())
+	}
+	if !cfg.Build.Rollback.IsValid() {
+		return nil, fmt.Errorf("invalid build.rollback %q, must be one of: %v", cfg.Build.Rollback, types.AllRollbackPolicies())
+	}
+
 	return &cfg, nil
 }
 
@@ -75,6 +103,17 @@ func DefaultConfig() *Config {
 		},
 		Build: BuildConfig{
 			DefaultLoopIterations: 10,
+			RepairAttempts:        2,
+			LearningsLimit:        10,
+			LearningsBudget:       4000,
+			Escalation:            types.EscalateBlock,
+			EscalationModel:       "opus",
+			SplitAfterBailouts:    2,
+			Rollback:              types.RollbackKeep,
+		},
+		Verify: VerifyConfig{
+			Timeout:     10 * time.Minute,
+			FlakyReruns: 2,
 		},
 	}
 }
@@ -97,4 +136,31 @@ func applyDefaults(cfg *Config) {
 	if cfg.Build.DefaultLoopIterations == 0 {
 		cfg.Build.DefaultLoopIterations = defaults.Build.DefaultLoopIterations
 	}
+	if cfg.Build.RepairAttempts == 0 {
+		cfg.Build.RepairAttempts = defaults.Build.RepairAttempts
+	}
+	if cfg.Build.LearningsLimit == 0 {
+		cfg.Build.LearningsLimit = defaults.Build.LearningsLimit
+	}
+	if cfg.Build.LearningsBudget == 0 {
+		cfg.Build.LearningsBudget = defaults.Build.LearningsBudget
+	}
+	if cfg.Build.Escalation == "" {
+		cfg.Build.Escalation = defaults.Build.Escalation
+	}
+	if cfg.Build.EscalationModel == "" {
+		cfg.Build.EscalationModel = defaults.Build.EscalationModel
+	}
+	if cfg.Build.SplitAfterBailouts == 0 {
+		cfg.Build.SplitAfterBailouts = defaults.Build.SplitAfterBailouts
+	}
+	if cfg.Build.Rollback == "" {
+		cfg.Build.Rollback = defaults.Build.Rollback
+	}
+	if cfg.Verify.Timeout == 0 {
+		cfg.Verify.Timeout = defaults.Verify.Timeout
+	}
+	if cfg.Verify.FlakyReruns == 0 {
+		cfg.Verify.FlakyReruns = defaults.Verify.FlakyReruns
+	}
 }
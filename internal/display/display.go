@@ -4,6 +4,7 @@ package display
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -16,6 +17,7 @@ type Display struct {
 	theme     *Theme
 	termWidth int
 	noColor   bool
+	out       io.Writer
 }
 
 // TokenStats holds token usage info for display
@@ -34,6 +36,7 @@ func NewWithOptions(noColor bool) *Display {
 	d := &Display{
 		termWidth: getTerminalWidth(),
 		noColor:   noColor,
+		out:       os.Stdout,
 	}
 	if noColor {
 		d.theme = NoColorTheme()
@@ -72,23 +75,23 @@ func (d *Display) RalphBox(title string, lines ...string) {
 
 	// Top border: ┌─ RALPH ─────────────────────────┐
 	topLine := BoxTopLeft + BoxHorizontal + " " + title + " " + strings.Repeat(BoxHorizontal, remainingWidth) + BoxTopRight
-	fmt.Println(d.theme.RalphBorder(topLine))
+	fmt.Fprintln(d.out, d.theme.RalphBorder(topLine))
 
 	// Content lines: │ text                            │
 	for _, line := range lines {
 		paddedLine := d.padRight(line, width-2)
-		fmt.Println(d.theme.RalphBorder(BoxVertical) + " " + d.theme.RalphText(paddedLine) + " " + d.theme.RalphBorder(BoxVertical))
+		fmt.Fprintln(d.out, d.theme.RalphBorder(BoxVertical)+" "+d.theme.RalphText(paddedLine)+" "+d.theme.RalphBorder(BoxVertical))
 	}
 
 	// Bottom border: └─────────────────────────────────┘
 	bottomLine := BoxBottomLeft + strings.Repeat(BoxHorizontal, width) + BoxBottomRight
-	fmt.Println(d.theme.RalphBorder(bottomLine))
+	fmt.Fprintln(d.out, d.theme.RalphBorder(bottomLine))
 }
 
 // RalphStatus prints a single-line Ralph status message (no box)
 func (d *Display) RalphStatus(symbol, message string) {
 	timestamp := GetTimestamp()
-	fmt.Printf("%s %s %s\n",
+	fmt.Fprintf(d.out, "%s %s %s\n",
 		d.theme.RalphBorder(timestamp),
 		symbol,
 		d.theme.RalphText(message))
@@ -122,7 +125,7 @@ func (d *Display) Resume(message string) {
 // ClaudeStart prints a header when Claude execution begins
 func (d *Display) ClaudeStart() {
 	timestamp := GetTimestamp()
-	fmt.Printf("  %s %s Sending to Claude...\n",
+	fmt.Fprintf(d.out, "  %s %s Sending to Claude...\n",
 		d.theme.Dim(timestamp),
 		d.theme.ClaudeTimestamp(GutterClaude))
 }
@@ -184,9 +187,9 @@ func (d *Display) Claude(text string, toolCount int) {
 
 	for i, line := range lines {
 		if i == 0 {
-			fmt.Printf("  %s %s%s %s\n", gutter, d.theme.Dim(timestamp), toolStr, d.theme.ClaudeText(line))
+			fmt.Fprintf(d.out, "  %s %s%s %s\n", gutter, d.theme.Dim(timestamp), toolStr, d.theme.ClaudeText(line))
 		} else {
-			fmt.Printf("  %s %s%s\n", d.theme.ClaudeTimestamp(GutterDot), strings.Repeat(" ", 10), d.theme.ClaudeText(line))
+			fmt.Fprintf(d.out, "  %s %s%s\n", d.theme.ClaudeTimestamp(GutterDot), strings.Repeat(" ", 10), d.theme.ClaudeText(line))
 		}
 	}
 }
@@ -208,9 +211,9 @@ func (d *Display) ClaudeWithTokens(text string, toolCount int, tokens TokenStats
 
 	for i, line := range lines {
 		if i == 0 {
-			fmt.Printf("  %s %s%s%s %s\n", gutter, d.theme.Dim(timestamp), toolStr, tokenStr, d.theme.ClaudeText(line))
+			fmt.Fprintf(d.out, "  %s %s%s%s %s\n", gutter, d.theme.Dim(timestamp), toolStr, tokenStr, d.theme.ClaudeText(line))
 		} else {
-			fmt.Printf("  %s %s%s\n", d.theme.ClaudeTimestamp(GutterDot), strings.Repeat(" ", 20), d.theme.ClaudeText(line))
+			fmt.Fprintf(d.out, "  %s %s%s\n", d.theme.ClaudeTimestamp(GutterDot), strings.Repeat(" ", 20), d.theme.ClaudeText(line))
 		}
 	}
 }
@@ -223,19 +226,19 @@ func (d *Display) ClaudeDone(result string) {
 		d.theme.ClaudeTimestamp(timestamp),
 		d.theme.ClaudeToolCount("[Done]"),
 		d.theme.ClaudeText(result))
-	fmt.Println(line)
+	fmt.Fprintln(d.out, line)
 }
 
 // ClaudeWorkingOn prints the "WORKING ON" banner for PRD selection
 func (d *Display) ClaudeWorkingOn(id string) {
 	banner := fmt.Sprintf(">>> WORKING ON: %s <<<", id)
-	fmt.Printf("\n%s%s\n\n", IndentClaude, d.theme.RalphLabel(banner))
+	fmt.Fprintf(d.out, "\n%s%s\n\n", IndentClaude, d.theme.RalphLabel(banner))
 }
 
 // SectionBreak prints a horizontal separator for iteration boundaries
 func (d *Display) SectionBreak() {
 	width := d.termWidth
-	fmt.Println(d.theme.Separator(strings.Repeat(SectionBreak, width)))
+	fmt.Fprintln(d.out, d.theme.Separator(strings.Repeat(SectionBreak, width)))
 }
 
 // Iteration prints the iteration banner with progress
@@ -243,40 +246,40 @@ func (d *Display) Iteration(current, max int, planName string, completed, total
 	d.SectionBreak()
 	line := fmt.Sprintf("Iteration %d/%d: %s (%d/%d plans done)",
 		current, max, d.theme.Info(planName), completed, total)
-	fmt.Println(line)
+	fmt.Fprintln(d.out, line)
 	d.SectionBreak()
 }
 
 // LoopHeader prints the loop mode header
 func (d *Display) LoopHeader() {
-	fmt.Println(d.theme.Bold("=== Ralph Autonomous Loop ==="))
-	fmt.Println()
+	fmt.Fprintln(d.out, d.theme.Bold("=== Ralph Autonomous Loop ==="))
+	fmt.Fprintln(d.out)
 }
 
 // AllComplete prints the completion message
 func (d *Display) AllComplete() {
-	fmt.Printf("\n%s All plans complete!\n", d.theme.Success(SymbolSuccess))
+	fmt.Fprintf(d.out, "\n%s All plans complete!\n", d.theme.Success(SymbolSuccess))
 }
 
 // LoopComplete prints the loop completion message
 func (d *Display) LoopComplete(message string, completed int) {
-	fmt.Printf("\n%s %s\n", d.theme.Success(SymbolSuccess), message)
-	fmt.Printf("   %d plans completed.\n", completed)
+	fmt.Fprintf(d.out, "\n%s %s\n", d.theme.Success(SymbolSuccess), message)
+	fmt.Fprintf(d.out, "   %d plans completed.\n", completed)
 }
 
 // LoopFailed prints the loop failure message
 func (d *Display) LoopFailed(planName string, err error, completed int) {
-	fmt.Printf("\n%s FAILED: %s\n", d.theme.Error(SymbolError), planName)
+	fmt.Fprintf(d.out, "\n%s FAILED: %s\n", d.theme.Error(SymbolError), planName)
 	if err != nil {
-		fmt.Printf("   Error: %v\n", err)
+		fmt.Fprintf(d.out, "   Error: %v\n", err)
 	}
-	fmt.Printf("\nStopping loop. %d plans complete, 1 failed.\n", completed)
-	fmt.Println("Run 'ralph status' for details.")
+	fmt.Fprintf(d.out, "\nStopping loop. %d plans complete, 1 failed.\n", completed)
+	fmt.Fprintln(d.out, "Run 'ralph status' for details.")
 }
 
 // MaxIterations prints the max iterations reached message
 func (d *Display) MaxIterations(max int) {
-	fmt.Printf("\nReached max iterations (%d). Run 'ralph run --loop' to continue.\n", max)
+	fmt.Fprintf(d.out, "\nReached max iterations (%d). Run 'ralph run --loop' to continue.\n", max)
 }
 
 // Tokens prints token usage stats in a Ralph box
@@ -287,7 +290,7 @@ func (d *Display) Tokens(total, input, output int) {
 
 // Duration prints execution duration
 func (d *Display) Duration(dur time.Duration) {
-	fmt.Printf("   Duration: %s\n", dur.Round(time.Second))
+	fmt.Fprintf(d.out, "   Duration: %s\n", dur.Round(time.Second))
 }
 
 // Theme returns the current theme for external use
@@ -339,7 +342,7 @@ func CreateProgressBar(completed, total, width int) string {
 // AnalysisStart prints header when analysis begins
 func (d *Display) AnalysisStart(observationCount int) {
 	timestamp := GetTimestamp()
-	fmt.Printf("\n%s %s %s\n",
+	fmt.Fprintf(d.out, "\n%s %s %s\n",
 		d.theme.Dim(timestamp),
 		d.theme.AnalysisGutter(GutterAnalysis),
 		d.theme.AnalysisText(fmt.Sprintf("Analyzing %d observations...", observationCount)))
@@ -350,9 +353,9 @@ func (d *Display) Analysis(text string) {
 	lines := d.wrapText(text, d.termWidth-15)
 	for i, line := range lines {
 		if i == 0 {
-			fmt.Printf("  %s %s\n", d.theme.AnalysisGutter(GutterAnalysis), d.theme.AnalysisText(line))
+			fmt.Fprintf(d.out, "  %s %s\n", d.theme.AnalysisGutter(GutterAnalysis), d.theme.AnalysisText(line))
 		} else {
-			fmt.Printf("  %s %s\n", d.theme.AnalysisGutter(GutterDot), d.theme.AnalysisText(line))
+			fmt.Fprintf(d.out, "  %s %s\n", d.theme.AnalysisGutter(GutterDot), d.theme.AnalysisText(line))
 		}
 	}
 }
@@ -360,7 +363,7 @@ func (d *Display) Analysis(text string) {
 // AnalysisComplete prints analysis completion
 func (d *Display) AnalysisComplete(modified, newPlans int) {
 	timestamp := GetTimestamp()
-	fmt.Printf("%s %s %s\n",
+	fmt.Fprintf(d.out, "%s %s %s\n",
 		d.theme.Dim(timestamp),
 		d.theme.AnalysisGutter(GutterAnalysis),
 		d.theme.Success(fmt.Sprintf("Analysis complete (modified: %d, new: %d)", modified, newPlans)))
@@ -0,0 +1,62 @@
+package display
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// outputMu keeps lines from concurrent prefixed displays from interleaving
+var outputMu sync.Mutex
+
+// WithPrefix returns a display that starts every line with prefix, for
+// telling apart the output of concurrent workers. Lines are written whole,
+// so output from several prefixed displays can share a terminal.
+func (d *Display) WithPrefix(prefix string) *Display {
+	prefixed := *d
+	label := prefix + " "
+	if !d.noColor {
+		label = d.theme.Info(prefix) + " "
+	}
+	prefixed.out = &prefixWriter{w: d.out, prefix: []byte(label)}
+	if prefixed.termWidth-len(prefix)-1 >= 40 {
+		prefixed.termWidth -= len(prefix) + 1
+	}
+	return &prefixed
+}
+
+// prefixWriter buffers partial lines and writes complete ones with a prefix
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, b...)
+	end := bytes.LastIndexByte(p.buf, '\n')
+	if end < 0 {
+		return len(b), nil
+	}
+
+	var out []byte
+	for _, line := range bytes.SplitAfter(p.buf[:end+1], []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		out = append(out, p.prefix...)
+		out = append(out, line...)
+	}
+	p.buf = append(p.buf[:0], p.buf[end+1:]...)
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if _, err := p.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
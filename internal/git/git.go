@@ -0,0 +1,284 @@
+// Package git manages the repository around a Ralph workspace: a branch per
+// PRD, a clean-tree check before each iteration, a commit after it and a
+// merge back once the PRD is complete.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/prd"
+)
+
+// BranchPrefix namespaces the branches Ralph creates
+const BranchPrefix = "ralph/"
+
+// subjectLimit is the longest commit subject line Ralph writes
+const subjectLimit = 72
+
+// BranchName returns the branch a PRD is executed on
+func BranchName(prdID string) string {
+	return BranchPrefix + prdID
+}
+
+// IsRepo reports whether dir is inside a git work tree
+func IsRepo(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// Head returns the current HEAD SHA, or "" outside a git repository
+func Head(dir string) string {
+	out, err := run(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// CurrentBranch returns the checked-out branch, or "" when HEAD is detached
+func CurrentBranch(dir string) string {
+	out, err := run(dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// Prefix returns dir's path relative to the top of its work tree, with a
+// trailing slash, or "" at the top
+func Prefix(dir string) string {
+	out, err := run(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// ChangedFiles lists files that differ from base, including uncommitted changes
+func ChangedFiles(dir, base string) []string {
+	return lines(dir, "diff", "--name-only", base)
+}
+
+// CommitsSince lists abbreviated SHAs of commits made after base, oldest first
+func CommitsSince(dir, base string) []string {
+	return lines(dir, "log", "--reverse", "--format=%h", base+"..HEAD")
+}
+
+// Dirty lists modified, staged and untracked paths under dir, leaving out
+// the excluded paths (relative to dir)
+func Dirty(dir string, exclude ...string) ([]string, error) {
+	out, err := output(dir, append([]string{"status", "--porcelain", "--untracked-files=all"}, pathspec(exclude)...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(out), nil
+}
+
+// parseStatus extracts paths from git status --porcelain output
+func parseStatus(out string) []string {
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		if _, renamed, ok := strings.Cut(path, " -> "); ok {
+			path = renamed
+		}
+		paths = append(paths, strings.Trim(path, `"`))
+	}
+	return paths
+}
+
+// CheckoutBranch switches dir to branch. A missing branch is created from
+// base and records base as its upstream, so BaseBranch knows where to merge
+// it back; an empty base creates it from HEAD. It reports whether the
+// branch was created.
+func CheckoutBranch(dir, branch, base string) (bool, error) {
+	if CurrentBranch(dir) == branch {
+		return false, nil
+	}
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		if _, err := run(dir, "checkout", "--quiet", branch); err != nil {
+			return false, fmt.Errorf("failed to check out %s: %w", branch, err)
+		}
+		return false, nil
+	}
+	args := []string{"checkout", "--quiet", "-b", branch}
+	if base != "" {
+		args = append(args, "--track", base)
+	}
+	if _, err := run(dir, args...); err != nil {
+		return false, fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+	return true, nil
+}
+
+// BaseBranch returns the local branch that branch was created from by
+// CheckoutBranch, or "" when it has none
+func BaseBranch(dir, branch string) string {
+	out, err := run(dir, "for-each-ref", "--format=%(upstream)", "refs/heads/"+branch)
+	if err != nil || !strings.HasPrefix(out, "refs/heads/") {
+		return ""
+	}
+	return strings.TrimPrefix(out, "refs/heads/")
+}
+
+// IsMerged reports whether every commit on branch is already on target
+func IsMerged(dir, branch, target string) bool {
+	_, err := run(dir, "merge-base", "--is-ancestor", branch, target)
+	return err == nil
+}
+
+// CommitAll stages every change under dir except the excluded paths and
+// commits it, returning the new commit's SHA, or "" when there was nothing
+// to commit
+func CommitAll(dir, message string, exclude ...string) (string, error) {
+	if _, err := run(dir, append([]string{"add", "--all"}, pathspec(exclude)...)...); err != nil {
+		return "", fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if _, err := run(dir, "diff", "--cached", "--quiet"); err == nil {
+		return "", nil
+	}
+	if _, err := run(dir, "commit", "--quiet", "-m", message); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+	return Head(dir), nil
+}
+
+// CommitMessage builds a conventional commit message for an iteration of p
+func CommitMessage(p *prd.PRD, a *prd.Attempt) string {
+	prefix := fmt.Sprintf("%s(%s): ", commitType(p.Tags), p.ID)
+	title := strings.Join(strings.Fields(p.Title), " ")
+	if room := subjectLimit - len(prefix); len(title) > room && room > 3 {
+		title = strings.TrimSpace(title[:room-3]) + "..."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prefix + title + "\n")
+	if a != nil {
+		sb.WriteString(fmt.Sprintf("\nIteration %d: %s\n", a.Iteration, outcome(a)))
+		if len(a.StepsCompleted) > 0 {
+			sb.WriteString("\nSteps completed:\n")
+			for _, step := range a.StepsCompleted {
+				sb.WriteString("- " + step + "\n")
+			}
+		}
+		if a.Blocker != "" {
+			sb.WriteString("\nBlocked: " + a.Blocker + "\n")
+		}
+	}
+	sb.WriteString("\nRalph-PRD: " + p.ID + "\n")
+	return sb.String()
+}
+
+// commitType maps PRD tags to a conventional commit type
+func commitType(tags []string) string {
+	for _, tag := range tags {
+		switch strings.ToLower(tag) {
+		case "bug", "bugfix", "fix":
+			return "fix"
+		case "docs", "documentation":
+			return "docs"
+		case "test", "tests":
+			return "test"
+		case "refactor", "perf", "chore", "build", "ci":
+			return strings.ToLower(tag)
+		}
+	}
+	return "feat"
+}
+
+func outcome(a *prd.Attempt) string {
+	if a.Outcome == "" {
+		return "unfinished"
+	}
+	return string(a.Outcome)
+}
+
+// DiffSince stages every change under dir except the excluded paths and
+// returns everything since base, committed or not, as a binary patch that
+// applies on top of base
+func DiffSince(dir, base string, exclude ...string) (string, error) {
+	spec := pathspec(exclude)
+
+	if _, err := run(dir, append([]string{"add", "--all"}, spec...)...); err != nil {
+		return "", fmt.Errorf("failed to stage changes: %w", err)
+	}
+	patch, err := output(dir, append([]string{"diff", "--cached", "--binary", base}, spec...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff against %s: %w", base, err)
+	}
+	return patch, nil
+}
+
+// ResetTo discards every change under dir since base, committed or not,
+// leaving the excluded paths alone. Save what it discards with DiffSince
+// first.
+func ResetTo(dir, base string, exclude ...string) error {
+	spec := pathspec(exclude)
+
+	if _, err := run(dir, append([]string{"add", "--all"}, spec...)...); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if Head(dir) != base {
+		if _, err := run(dir, "reset", "--quiet", "--soft", base); err != nil {
+			return fmt.Errorf("failed to reset to %s: %w", base, err)
+		}
+	}
+	if _, err := run(dir, append([]string{"restore", "--source=" + base, "--staged", "--worktree"}, spec...)...); err != nil {
+		return fmt.Errorf("failed to restore files from %s: %w", base, err)
+	}
+	if _, err := run(dir, append([]string{"clean", "--quiet", "--force", "-d"}, spec...)...); err != nil {
+		return fmt.Errorf("failed to remove untracked files: %w", err)
+	}
+	return nil
+}
+
+// pathspec selects everything under the current directory except exclude
+func pathspec(exclude []string) []string {
+	spec := []string{"--", "."}
+	for _, path := range exclude {
+		spec = append(spec, ":(exclude)"+path)
+	}
+	return spec
+}
+
+// run executes git in dir and returns its trimmed stdout
+func run(dir string, args ...string) (string, error) {
+	out, err := output(dir, args...)
+	return strings.TrimSpace(out), err
+}
+
+// output executes git in dir and returns its stdout
+func output(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+func lines(dir string, args ...string) []string {
+	out, err := run(dir, args...)
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
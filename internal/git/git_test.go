@@ -0,0 +1,330 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := " M main.go\n?? notes/todo.md\nR  old.go -> new.go\nA  \"with space.go\"\n"
+	want := []string{"main.go", "notes/todo.md", "new.go", "with space.go"}
+	if got := parseStatus(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStatus() = %v, want %v", got, want)
+	}
+	if got := parseStatus(""); got != nil {
+		t.Errorf("parseStatus(\"\") = %v, want nil", got)
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		title       string
+		attempt     *prd.Attempt
+		wantSubject string
+		wantBody    []string
+	}{
+		{
+			name:        "feature",
+			title:       "Add rate limiter",
+			attempt:     &prd.Attempt{Iteration: 2, Outcome: types.OutcomePartial, StepsCompleted: []string{"token bucket"}},
+			wantSubject: "feat(add-rate-limiter): Add rate limiter",
+			wantBody:    []string{"Iteration 2: partial", "- token bucket", "Ralph-PRD: add-rate-limiter"},
+		},
+		{
+			name:        "bug tag",
+			tags:        []string{"api", "Bug"},
+			title:       "Fix login",
+			attempt:     &prd.Attempt{Iteration: 1, Outcome: types.OutcomeBlocked, Blocker: "no credentials"},
+			wantSubject: "fix(add-rate-limiter): Fix login",
+			wantBody:    []string{"Blocked: no credentials"},
+		},
+		{
+			name:        "long title is truncated",
+			title:       strings.Repeat("word ", 30),
+			wantSubject: "feat(add-rate-limiter): word word word word word word word word word...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &prd.PRD{ID: "add-rate-limiter", Title: tt.title, Tags: tt.tags}
+			msg := CommitMessage(p, tt.attempt)
+			subject, body, _ := strings.Cut(msg, "\n")
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			if len(subject) > subjectLimit {
+				t.Errorf("subject is %d characters, limit %d", len(subject), subjectLimit)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body missing %q:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+// newTestRepo creates a git repository with one commit containing files
+func newTestRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	mustGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	mustGit(t, dir, "config", "user.name", "Test")
+	mustGit(t, dir, "config", "user.email", "test@example.com")
+	mustGit(t, dir, "config", "commit.gpgsign", "false")
+	commitFiles(t, dir, "init", files)
+	return dir
+}
+
+// commitFiles writes files into dir and commits them
+func commitFiles(t *testing.T, dir, message string, files map[string]string) {
+	t.Helper()
+	writeFiles(t, dir, files)
+	mustGit(t, dir, "add", "--all")
+	mustGit(t, dir, "commit", "--quiet", "-m", message)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func mustGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := run(dir, args...)
+	if err != nil {
+		t.Fatalf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+func TestResetTo(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{
+		"main.go":          "package main\n",
+		".ralph/prd.json":  "{}\n",
+		"docs/keep.md":     "keep\n",
+		"assets/logo.bin":  "\x00\x01old",
+		"docs/removed.txt": "gone soon\n",
+	})
+	base := Head(dir)
+
+	// A committed change, then uncommitted edits of every kind
+	commitFiles(t, dir, "iteration", map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	binary := "\x00\xff\x10binary\x00"
+	writeFiles(t, dir, map[string]string{
+		"new/untracked.go":  "package new\n",
+		"assets/logo.bin":   binary,
+		".ralph/prd.json":   `{"features":[]}` + "\n",
+		".ralph/runs/1.log": "log\n",
+	})
+	if err := os.Remove(filepath.Join(dir, "docs/removed.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := DiffSince(dir, base, ".ralph")
+	if err != nil {
+		t.Fatalf("DiffSince() error = %v", err)
+	}
+	for _, want := range []string{"main.go", "new/untracked.go", "assets/logo.bin", "GIT binary patch", "docs/removed.txt"} {
+		if !strings.Contains(patch, want) {
+			t.Errorf("patch missing %q", want)
+		}
+	}
+	if strings.Contains(patch, ".ralph/") {
+		t.Error("patch includes excluded .ralph/")
+	}
+
+	if err := ResetTo(dir, base, ".ralph"); err != nil {
+		t.Fatalf("ResetTo() error = %v", err)
+	}
+	if got := Head(dir); got != base {
+		t.Errorf("HEAD = %s, want %s", got, base)
+	}
+	if got := readFile(t, filepath.Join(dir, "main.go")); got != "package main\n" {
+		t.Errorf("main.go = %q, want the base version", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "assets/logo.bin")); got != "\x00\x01old" {
+		t.Errorf("logo.bin = %q, want the base version", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "docs/removed.txt")); got != "gone soon\n" {
+		t.Errorf("removed.txt = %q, want it restored", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new/untracked.go")); !os.IsNotExist(err) {
+		t.Errorf("untracked file survived the reset: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, ".ralph/prd.json")); got != `{"features":[]}`+"\n" {
+		t.Errorf(".ralph/prd.json = %q, want the edit kept", got)
+	}
+	if got := readFile(t, filepath.Join(dir, ".ralph/runs/1.log")); got != "log\n" {
+		t.Errorf(".ralph/runs/1.log = %q, want it kept", got)
+	}
+
+	// The patch brings the discarded work back on top of base
+	apply := exec.Command("git", "apply", "--binary", "--index")
+	apply.Dir = dir
+	apply.Stdin = strings.NewReader(patch)
+	var stderr bytes.Buffer
+	apply.Stderr = &stderr
+	if err := apply.Run(); err != nil {
+		t.Fatalf("git apply: %v: %s", err, stderr.String())
+	}
+	if got := readFile(t, filepath.Join(dir, "assets/logo.bin")); got != binary {
+		t.Errorf("logo.bin after apply = %q, want %q", got, binary)
+	}
+	if got := readFile(t, filepath.Join(dir, "new/untracked.go")); got != "package new\n" {
+		t.Errorf("untracked.go after apply = %q", got)
+	}
+}
+
+func TestResetToClean(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	base := Head(dir)
+
+	patch, err := DiffSince(dir, base, ".ralph")
+	if err != nil || patch != "" {
+		t.Fatalf("DiffSince() = %q, %v, want empty patch", patch, err)
+	}
+	if err := ResetTo(dir, base, ".ralph"); err != nil {
+		t.Fatalf("ResetTo() error = %v", err)
+	}
+}
+
+func TestCheckoutBranch(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+
+	tests := []struct {
+		name        string
+		branch      string
+		base        string
+		wantCreated bool
+		wantBase    string
+	}{
+		{"creates a new branch from a base", "ralph/first", "main", true, "main"},
+		{"already checked out", "ralph/first", "main", false, "main"},
+		// Created from the first PRD's branch, but based on main all the same
+		{"creates a sibling branch", "ralph/second", "main", true, "main"},
+		{"switches to an existing branch", "main", "", false, ""},
+		{"back to the PRD branch", "ralph/first", "", false, "main"},
+		{"creates a branch from HEAD", "ralph/third", "", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := CheckoutBranch(dir, tt.branch, tt.base)
+			if err != nil {
+				t.Fatalf("CheckoutBranch() error = %v", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("CheckoutBranch() created = %v, want %v", created, tt.wantCreated)
+			}
+			if got := CurrentBranch(dir); got != tt.branch {
+				t.Errorf("CurrentBranch() = %q, want %q", got, tt.branch)
+			}
+			if got := BaseBranch(dir, tt.branch); got != tt.wantBase {
+				t.Errorf("BaseBranch() = %q, want %q", got, tt.wantBase)
+			}
+		})
+	}
+}
+
+func TestCommitAll(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	base := Head(dir)
+
+	sha, err := CommitAll(dir, "nothing", ".ralph")
+	if err != nil || sha != "" {
+		t.Fatalf("CommitAll() on a clean tree = %q, %v, want no commit", sha, err)
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"main.go":         "package main\n\nfunc main() {}\n",
+		"pkg/new.go":      "package pkg\n",
+		".ralph/prd.json": "{}\n",
+		".ralph/lock":     "{}\n",
+	})
+	sha, err = CommitAll(dir, "feat: add things", ".ralph")
+	if err != nil {
+		t.Fatalf("CommitAll() error = %v", err)
+	}
+	if sha == "" || sha != Head(dir) {
+		t.Fatalf("CommitAll() = %q, want the new HEAD %q", sha, Head(dir))
+	}
+	if got, want := ChangedFiles(dir, base), []string{"main.go", "pkg/new.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", got, want)
+	}
+	if got := CommitsSince(dir, base); len(got) != 1 {
+		t.Errorf("CommitsSince() = %v, want one commit", got)
+	}
+	if dirty, _ := Dirty(dir, ".ralph"); len(dirty) != 0 {
+		t.Errorf("Dirty() = %v, want only excluded paths left", dirty)
+	}
+}
+
+func TestBranchPerPRD(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	state := map[string]string{".ralph/prd.json": `{"features":["a","b"]}` + "\n"}
+
+	// Each PRD commits its work on its own branch; Ralph's state stays out of both
+	for _, id := range []string{"a", "b"} {
+		if _, err := CheckoutBranch(dir, BranchName(id), "main"); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, dir, state)
+		writeFiles(t, dir, map[string]string{id + ".go": "package main\n"})
+		if _, err := CommitAll(dir, "feat: "+id, ".ralph"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CheckoutBranch(dir, "main", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Going back to an older PRD's branch keeps the latest state and only its own work
+	state[".ralph/prd.json"] = `{"features":["a","b","c"]}` + "\n"
+	writeFiles(t, dir, state)
+	if _, err := CheckoutBranch(dir, BranchName("a"), ""); err != nil {
+		t.Fatalf("CheckoutBranch() error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, ".ralph/prd.json")); got != state[".ralph/prd.json"] {
+		t.Errorf(".ralph/prd.json = %q, want the latest state", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.go")); err != nil {
+		t.Errorf("a.go missing on %s: %v", BranchName("a"), err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.go")); !os.IsNotExist(err) {
+		t.Errorf("b.go present on %s", BranchName("a"))
+	}
+	if files := lines(dir, "ls-tree", "-r", "--name-only", BranchName("b")); len(files) == 0 || strings.Contains(strings.Join(files, " "), ".ralph") {
+		t.Errorf("%s tree = %v, want code without .ralph/", BranchName("b"), files)
+	}
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Merge-back methods reported by MergeBack
+const (
+	MergeFastForward = "fast-forward"
+	MergeRebase      = "rebase"
+)
+
+// ConflictError reports a branch that could not be rebased onto its target
+type ConflictError struct {
+	Branch string
+	Target string
+	Files  []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s conflicts with %s in %s", e.Branch, e.Target, strings.Join(e.Files, ", "))
+}
+
+// AddWorktree checks out branch in a new worktree at path, creating the
+// branch from HEAD if needed. An existing worktree at path is reused.
+// It reports whether a worktree was created.
+func AddWorktree(dir, path, branch string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	args := []string{"worktree", "add", "--quiet", path, branch}
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		args = []string{"worktree", "add", "--quiet", "-b", branch, path, "HEAD"}
+	}
+	if _, err := run(dir, args...); err != nil {
+		return false, fmt.Errorf("failed to add worktree for %s: %w", branch, err)
+	}
+	return true, nil
+}
+
+// RemoveWorktree deletes the worktree at path; its branch is kept
+func RemoveWorktree(dir, path string) error {
+	if _, err := run(dir, "worktree", "remove", path); err != nil {
+		return fmt.Errorf("failed to remove worktree %s: %w", path, err)
+	}
+	return nil
+}
+
+// MergeBack brings branch, checked out in worktree, into the branch checked
+// out in dir. It fast-forwards when it can and otherwise rebases branch onto
+// the target first. A rebase that conflicts is aborted and returned as a
+// *ConflictError, leaving both branches as they were.
+func MergeBack(dir, worktree, branch string) (string, error) {
+	target := CurrentBranch(dir)
+	if target == "" {
+		return "", fmt.Errorf("cannot merge %s: HEAD is detached", branch)
+	}
+
+	method, err := rebaseOnto(worktree, branch, target)
+	if err != nil {
+		return "", err
+	}
+	if _, err := run(dir, "merge", "--quiet", "--ff-only", branch); err != nil {
+		return "", fmt.Errorf("failed to fast-forward %s to %s: %w", target, branch, err)
+	}
+	return method, nil
+}
+
+// MergeInto brings branch into target within a single checkout, the way
+// MergeBack does, and leaves target checked out in dir. On a conflict dir is
+// left on branch, which is as it was.
+func MergeInto(dir, branch, target string) (string, error) {
+	if _, err := CheckoutBranch(dir, branch, ""); err != nil {
+		return "", err
+	}
+	method, err := rebaseOnto(dir, branch, target)
+	if err != nil {
+		return "", err
+	}
+	if _, err := CheckoutBranch(dir, target, ""); err != nil {
+		return "", err
+	}
+	if _, err := run(dir, "merge", "--quiet", "--ff-only", branch); err != nil {
+		return "", fmt.Errorf("failed to fast-forward %s to %s: %w", target, branch, err)
+	}
+	return method, nil
+}
+
+// rebaseOnto rebases branch, checked out in dir, onto target unless target
+// is already part of it, and reports the merge method that leaves. A
+// conflicting rebase is aborted and returned as a *ConflictError.
+func rebaseOnto(dir, branch, target string) (string, error) {
+	if _, err := run(dir, "merge-base", "--is-ancestor", target, branch); err == nil {
+		return MergeFastForward, nil
+	}
+	if _, err := run(dir, "rebase", "--quiet", target); err != nil {
+		conflict := &ConflictError{Branch: branch, Target: target, Files: lines(dir, "diff", "--name-only", "--diff-filter=U")}
+		if _, abortErr := run(dir, "rebase", "--abort"); abortErr != nil {
+			return "", fmt.Errorf("failed to abort rebase of %s: %w", branch, abortErr)
+		}
+		if len(conflict.Files) == 0 {
+			return "", fmt.Errorf("failed to rebase %s onto %s: %w", branch, target, err)
+		}
+		return "", conflict
+	}
+	return MergeRebase, nil
+}
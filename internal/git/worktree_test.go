@@ -0,0 +1,206 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newWorktree adds a worktree on a new PRD branch of dir
+func newWorktree(t *testing.T, dir, prdID string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), prdID)
+	created, err := AddWorktree(dir, path, BranchName(prdID))
+	if err != nil {
+		t.Fatalf("AddWorktree() error = %v", err)
+	}
+	if !created {
+		t.Fatal("AddWorktree() created = false for a new worktree")
+	}
+	return path
+}
+
+func TestAddWorktree(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	path := newWorktree(t, dir, "feature")
+
+	if got := CurrentBranch(path); got != BranchName("feature") {
+		t.Errorf("worktree branch = %q, want %q", got, BranchName("feature"))
+	}
+	if got := CurrentBranch(dir); got != "main" {
+		t.Errorf("workspace branch = %q, want main", got)
+	}
+	created, err := AddWorktree(dir, path, BranchName("feature"))
+	if err != nil || created {
+		t.Errorf("AddWorktree() on an existing worktree = %v, %v, want reused", created, err)
+	}
+
+	// A worktree removed earlier comes back on its existing branch
+	commitFiles(t, path, "feat: work", map[string]string{"feature.go": "package main\n"})
+	if err := RemoveWorktree(dir, path); err != nil {
+		t.Fatalf("RemoveWorktree() error = %v", err)
+	}
+	if _, err := AddWorktree(dir, path, BranchName("feature")); err != nil {
+		t.Fatalf("AddWorktree() on an existing branch error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "feature.go")); err != nil {
+		t.Errorf("branch work missing from re-added worktree: %v", err)
+	}
+}
+
+func TestMergeBackFastForward(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	path := newWorktree(t, dir, "feature")
+	commitFiles(t, path, "feat: add feature", map[string]string{"feature.go": "package main\n"})
+
+	method, err := MergeBack(dir, path, BranchName("feature"))
+	if err != nil {
+		t.Fatalf("MergeBack() error = %v", err)
+	}
+	if method != MergeFastForward {
+		t.Errorf("MergeBack() method = %q, want %q", method, MergeFastForward)
+	}
+	if Head(dir) != Head(path) {
+		t.Errorf("main at %s, want the branch head %s", Head(dir), Head(path))
+	}
+	if got := readFile(t, filepath.Join(dir, "feature.go")); got != "package main\n" {
+		t.Errorf("feature.go on main = %q", got)
+	}
+}
+
+func TestMergeBackRebase(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	path := newWorktree(t, dir, "feature")
+	commitFiles(t, path, "feat: add feature", map[string]string{"feature.go": "package main\n"})
+
+	// Another PRD merged into main in the meantime
+	commitFiles(t, dir, "feat: other work", map[string]string{"other.go": "package main\n"})
+	moved := Head(dir)
+
+	method, err := MergeBack(dir, path, BranchName("feature"))
+	if err != nil {
+		t.Fatalf("MergeBack() error = %v", err)
+	}
+	if method != MergeRebase {
+		t.Errorf("MergeBack() method = %q, want %q", method, MergeRebase)
+	}
+	if got := CommitsSince(dir, moved); len(got) != 1 {
+		t.Errorf("main has %v on top of the other work, want the one rebased commit", got)
+	}
+	if got, want := ChangedFiles(dir, moved), []string{"feature.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.go")); err != nil {
+		t.Errorf("other work missing after merge: %v", err)
+	}
+}
+
+func TestMergeBackConflict(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	path := newWorktree(t, dir, "feature")
+	commitFiles(t, path, "feat: feature version", map[string]string{"main.go": "package main\n\n// feature\n"})
+	branchHead := Head(path)
+
+	commitFiles(t, dir, "feat: main version", map[string]string{"main.go": "package main\n\n// main\n"})
+	mainHead := Head(dir)
+
+	_, err := MergeBack(dir, path, BranchName("feature"))
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("MergeBack() error = %v, want a *ConflictError", err)
+	}
+	if !reflect.DeepEqual(conflict.Files, []string{"main.go"}) || conflict.Target != "main" {
+		t.Errorf("conflict = %+v, want main.go against main", conflict)
+	}
+
+	// Both branches are left as they were, with no rebase in progress
+	if Head(dir) != mainHead {
+		t.Errorf("main moved to %s, want %s", Head(dir), mainHead)
+	}
+	if Head(path) != branchHead {
+		t.Errorf("branch moved to %s, want %s", Head(path), branchHead)
+	}
+	if got := readFile(t, filepath.Join(dir, "main.go")); got != "package main\n\n// main\n" {
+		t.Errorf("main.go on main = %q, want main's version", got)
+	}
+	for _, d := range []string{dir, path} {
+		if dirty, _ := Dirty(d); len(dirty) != 0 {
+			t.Errorf("%s left dirty: %v", d, dirty)
+		}
+	}
+	if _, err := run(path, "rev-parse", "--verify", "--quiet", "REBASE_HEAD"); err == nil {
+		t.Error("rebase still in progress in the worktree")
+	}
+}
+
+func TestMergeInto(t *testing.T) {
+	tests := []struct {
+		name         string
+		mainFiles    map[string]string // committed on main after the branch was created
+		wantMethod   string
+		wantConflict bool
+	}{
+		{"fast-forward", nil, MergeFastForward, false},
+		{"rebase onto moved main", map[string]string{"other.go": "package main\n"}, MergeRebase, false},
+		{"conflict", map[string]string{"feature.go": "package other\n"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+			branch := BranchName("feature")
+			if _, err := CheckoutBranch(dir, branch, "main"); err != nil {
+				t.Fatal(err)
+			}
+			commitFiles(t, dir, "feat: add feature", map[string]string{"feature.go": "package main\n"})
+			branchHead := Head(dir)
+			if _, err := CheckoutBranch(dir, "main", ""); err != nil {
+				t.Fatal(err)
+			}
+			if tt.mainFiles != nil {
+				commitFiles(t, dir, "feat: other work", tt.mainFiles)
+			}
+			mainHead := Head(dir)
+
+			method, err := MergeInto(dir, branch, "main")
+			if tt.wantConflict {
+				var conflict *ConflictError
+				if !errors.As(err, &conflict) {
+					t.Fatalf("MergeInto() error = %v, want a *ConflictError", err)
+				}
+				// The branch stays checked out as it was for a manual merge
+				if got := CurrentBranch(dir); got != branch {
+					t.Errorf("CurrentBranch() = %q, want %q", got, branch)
+				}
+				if Head(dir) != branchHead {
+					t.Errorf("branch moved to %s, want %s", Head(dir), branchHead)
+				}
+				if got := lines(dir, "rev-parse", "main"); len(got) != 1 || got[0] != mainHead {
+					t.Errorf("main moved to %v, want %s", got, mainHead)
+				}
+				if dirty, _ := Dirty(dir); len(dirty) != 0 {
+					t.Errorf("left dirty: %v", dirty)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("MergeInto() error = %v", err)
+			}
+			if method != tt.wantMethod {
+				t.Errorf("MergeInto() method = %q, want %q", method, tt.wantMethod)
+			}
+			if got := CurrentBranch(dir); got != "main" {
+				t.Errorf("CurrentBranch() = %q, want main", got)
+			}
+			if !IsMerged(dir, branch, "main") {
+				t.Errorf("%s not merged into main", branch)
+			}
+			if got, want := ChangedFiles(dir, mainHead), []string{"feature.go"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("ChangedFiles() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -2,15 +2,23 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/daydemir/ralph/internal/proc"
 	"github.com/daydemir/ralph/internal/utils"
 )
 
+// killGrace is how long Claude's process group gets to exit after SIGTERM
+// before it is sent SIGKILL
+const killGrace = 5 * time.Second
+
 // ExecuteOptions contains options for Claude execution
 type ExecuteOptions struct {
 	Prompt       string
@@ -18,6 +26,9 @@ type ExecuteOptions struct {
 	Model        string
 	AllowedTools []string
 	WorkDir      string
+	// OnReap is told about the processes that were still running in Claude's
+	// process group when the session ended, after they have been stopped
+	OnReap func([]proc.Process)
 }
 
 // Claude implements the Backend interface for Claude Code CLI
@@ -47,13 +58,35 @@ func (c *Claude) Execute(ctx context.Context, opts ExecuteOptions) (io.ReadClose
 	cmd := exec.CommandContext(ctx, c.BinaryPath, args...)
 	cmd.Dir = opts.WorkDir
 	cmd.Stderr = os.Stderr
+	// Claude gets its own process group: Ctrl-C reaches Ralph only, and
+	// cancelling stops the dev servers and test runners Claude started too.
+	// The group gets SIGTERM; Claude itself is killed if it outlasts
+	// killGrace, and the rest of the group is stopped once Claude exits.
+	reader := &cmdReader{cmd: cmd, onReap: opts.OnReap, done: make(chan struct{})}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.WaitDelay = killGrace
+	cmd.Cancel = func() error {
+		group, _ := proc.Group(cmd.Process.Pid)
+		for _, p := range group {
+			if p.PID != cmd.Process.Pid {
+				reader.reaped = append(reader.reaped, p)
+			}
+		}
+		return terminateGroup(cmd.Process.Pid)
+	}
 
-	stdout, err := cmd.StdoutPipe()
+	// A plain pipe rather than StdoutPipe: processes Claude leaves behind
+	// inherit the write end, so output only ends once the group is reaped
+	stdout, pw, err := os.Pipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
+	cmd.Stdout = pw
 
-	if err := cmd.Start(); err != nil {
+	err = cmd.Start()
+	pw.Close()
+	if err != nil {
+		stdout.Close()
 		if strings.Contains(err.Error(), "executable file not found") {
 			return nil, utils.ClaudeNotFoundError()
 		}
@@ -61,10 +94,9 @@ func (c *Claude) Execute(ctx context.Context, opts ExecuteOptions) (io.ReadClose
 	}
 
 	// Return a wrapper that waits for the command when closed
-	return &cmdReader{
-		ReadCloser: stdout,
-		cmd:        cmd,
-	}, nil
+	reader.ReadCloser = stdout
+	go reader.wait()
+	return reader, nil
 }
 
 // ExecuteInteractive runs Claude Code in interactive mode
@@ -91,7 +123,7 @@ func (c *Claude) buildArgs(opts ExecuteOptions, interactive bool) []string {
 		args = append(args, "--model", opts.Model)
 	}
 
-	// Prompt (only for non-interactive)
+	// Prompt (interactive sessions get it as a positional argument below)
 	if !interactive && opts.Prompt != "" {
 		args = append(args, "-p", opts.Prompt)
 	}
@@ -109,20 +141,64 @@ func (c *Claude) buildArgs(opts ExecuteOptions, interactive bool) []string {
 	// Context files
 	args = append(args, opts.ContextFiles...)
 
+	// Initial prompt for interactive sessions. It goes first so variadic
+	// flags like --allowedTools don't swallow it.
+	if interactive && opts.Prompt != "" {
+		args = append([]string{opts.Prompt}, args...)
+	}
+
 	return args
 }
 
-// cmdReader wraps an io.ReadCloser and waits for the command on close
+// cmdReader wraps Claude's output and waits for the command on close
 type cmdReader struct {
 	io.ReadCloser
-	cmd *exec.Cmd
+	cmd    *exec.Cmd
+	onReap func([]proc.Process)
+	reaped []proc.Process // Claude's children, once its process group is stopped
+	done   chan struct{}  // closed once Claude has exited and its group is stopped
+	err    error
+}
+
+// wait waits for Claude to exit, then stops anything it left running in the
+// background, which also closes their copies of its output
+func (r *cmdReader) wait() {
+	defer close(r.done)
+	r.err = r.cmd.Wait()
+	left, _ := proc.KillGroup(r.cmd.Process.Pid, killGrace)
+	r.reaped = mergeProcesses(r.reaped, left)
 }
 
 func (r *cmdReader) Close() error {
 	closeErr := r.ReadCloser.Close()
-	waitErr := r.cmd.Wait()
-	if waitErr != nil {
-		return waitErr
+	<-r.done
+	if len(r.reaped) > 0 && r.onReap != nil {
+		r.onReap(r.reaped)
+	}
+	if r.err != nil {
+		return r.err
 	}
 	return closeErr
 }
+
+// mergeProcesses combines two process lists, dropping repeated PIDs
+func mergeProcesses(a, b []proc.Process) []proc.Process {
+	seen := make(map[int]bool)
+	var merged []proc.Process
+	for _, p := range append(append([]proc.Process{}, a...), b...) {
+		if !seen[p.PID] {
+			seen[p.PID] = true
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// terminateGroup asks every process in the process group led by pid to exit
+func terminateGroup(pid int) error {
+	err := syscall.Kill(-pid, syscall.SIGTERM)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
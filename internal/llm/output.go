@@ -6,6 +6,7 @@ import (
 	"io"
 	"regexp"
 	"strings"
+	"sync/atomic"
 
 	"github.com/daydemir/ralph/internal/display"
 )
@@ -54,6 +55,7 @@ type TokenStats struct {
 	OutputTokens    int
 	TotalTokens     int
 	CacheReadTokens int
+	CostUSD         float64 // as reported by Claude at the end of the session
 }
 
 // OutputHandler handles parsed stream events
@@ -78,6 +80,7 @@ type StreamEvent struct {
 	Type    string          `json:"type"`
 	Message *MessageContent `json:"message,omitempty"`
 	Result  string          `json:"result,omitempty"`
+	CostUSD float64         `json:"total_cost_usd,omitempty"` // result events only
 }
 
 // MessageContent represents the message field in stream events
@@ -86,7 +89,7 @@ type MessageContent struct {
 	Usage   *UsageBlock    `json:"usage,omitempty"`
 }
 
-// ContentBlock represents a content block (text or tool_use)
+// ContentBlock represents a content block (text, tool_use or tool_result)
 type ContentBlock struct {
 	Type string `json:"type"`
 	Text string `json:"text,omitempty"`
@@ -110,12 +113,16 @@ type ConsoleHandler struct {
 	failure           *FailureSignal
 	tokenStats        TokenStats
 	tokenThreshold    int
+	tokenLimitHit     bool // Claude was killed for exceeding tokenThreshold
 	planComplete      bool
 	bailoutSignal     *FailureSignal // Separate tracking for BAILOUT (soft failure)
 	lastDoneText      string         // Track last done message to prevent duplicates
 	onTerminate       func()         // Callback to kill Claude process when token limit exceeded
 	capturedOutput    []string       // All output text for error recovery
 	lastToolCall      string         // Last tool that was called
+	toolsRunning      atomic.Int32   // tool calls started without a result yet
+	stopRequested     atomic.Bool    // RequestStop was called
+	stopped           atomic.Bool    // onTerminate was called for a stop request
 }
 
 func NewConsoleHandler() *ConsoleHandler {
@@ -153,6 +160,37 @@ func NewConsoleHandlerWithTerminate(d *display.Display, onTerminate func()) *Con
 func (h *ConsoleHandler) OnToolUse(name string) {
 	h.toolCount++
 	h.lastToolCall = name
+	h.toolsRunning.Add(1)
+}
+
+// onToolResult ends a tool call, stopping the session if a stop is pending
+func (h *ConsoleHandler) onToolResult() {
+	if h.toolsRunning.Add(-1) < 0 {
+		h.toolsRunning.Store(0)
+	}
+	if h.stopRequested.Load() && h.toolsRunning.Load() == 0 {
+		h.stop()
+	}
+}
+
+// RequestStop asks the session to end once its running tool calls finish,
+// or right away when none are running. Safe to call from another goroutine.
+func (h *ConsoleHandler) RequestStop() {
+	h.stopRequested.Store(true)
+	if h.toolsRunning.Load() == 0 {
+		h.stop()
+	}
+}
+
+// StopRequested reports whether RequestStop was called
+func (h *ConsoleHandler) StopRequested() bool {
+	return h.stopRequested.Load()
+}
+
+func (h *ConsoleHandler) stop() {
+	if h.stopped.CompareAndSwap(false, true) && h.onTerminate != nil {
+		h.onTerminate()
+	}
 }
 
 func (h *ConsoleHandler) OnText(text string) {
@@ -215,14 +253,21 @@ func (h *ConsoleHandler) OnTokenUsage(usage TokenStats) {
 	h.tokenStats.InputTokens += usage.InputTokens
 	h.tokenStats.OutputTokens += usage.OutputTokens
 	h.tokenStats.CacheReadTokens += usage.CacheReadTokens
+	h.tokenStats.CostUSD += usage.CostUSD
 	h.tokenStats.TotalTokens = h.tokenStats.InputTokens + h.tokenStats.OutputTokens
 
 	// Check threshold and trigger termination if exceeded
 	if h.tokenStats.TotalTokens >= h.tokenThreshold && h.onTerminate != nil {
+		h.tokenLimitHit = true
 		h.onTerminate()
 	}
 }
 
+// HitTokenLimit reports whether the session was killed for exceeding the token threshold
+func (h *ConsoleHandler) HitTokenLimit() bool {
+	return h.tokenLimitHit
+}
+
 func (h *ConsoleHandler) HasFailed() bool {
 	return h.failure != nil
 }
@@ -384,7 +429,19 @@ func ParseStream(reader io.Reader, handler OutputHandler, onTerminate func()) er
 					}
 				}
 			}
+		case "user":
+			// Tool results come back to Claude as user messages
+			if ch, ok := handler.(*ConsoleHandler); ok && event.Message != nil {
+				for _, content := range event.Message.Content {
+					if content.Type == "tool_result" {
+						ch.onToolResult()
+					}
+				}
+			}
 		case "result":
+			if event.CostUSD > 0 {
+				handler.OnTokenUsage(TokenStats{CostUSD: event.CostUSD})
+			}
 			// Check for failure/termination signals in result events too
 			// These can appear when Claude outputs them in its final message
 			if match := taskFailedPattern.FindStringSubmatch(event.Result); len(match) > 1 {
@@ -3,6 +3,8 @@ package llm
 import (
 	"strings"
 	"testing"
+
+	"github.com/daydemir/ralph/internal/display"
 )
 
 func TestSignalDetection(t *testing.T) {
@@ -201,3 +203,32 @@ func TestOnTerminateCallback(t *testing.T) {
 		t.Error("Expected failure signal to be recorded")
 	}
 }
+
+func TestRequestStop(t *testing.T) {
+	toolUse := `{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Bash"}]}}`
+	toolResult := `{"type":"user","message":{"content":[{"type":"tool_result"}]}}`
+
+	terminated := 0
+	handler := NewConsoleHandlerWithTerminate(display.New(), func() { terminated++ })
+
+	ParseStream(strings.NewReader(toolUse+"\n"), handler, nil)
+	handler.RequestStop()
+	if terminated != 0 {
+		t.Fatal("stopped while a tool call was running")
+	}
+
+	ParseStream(strings.NewReader(toolResult+"\n"), handler, nil)
+	if terminated != 1 {
+		t.Fatalf("onTerminate called %d times after the tool call finished, want 1", terminated)
+	}
+	handler.RequestStop()
+	if terminated != 1 || !handler.StopRequested() {
+		t.Errorf("onTerminate called %d times after a repeated stop, want 1", terminated)
+	}
+
+	idle := NewConsoleHandlerWithTerminate(display.New(), func() { terminated++ })
+	idle.RequestStop()
+	if terminated != 2 {
+		t.Error("idle session was not stopped right away")
+	}
+}
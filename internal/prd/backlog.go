@@ -0,0 +1,452 @@
+package prd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/utils"
+)
+
+// Backlog is the ordered list of PRDs stored in .ralph/prd.json
+type Backlog struct {
+	Features []*PRD `json:"features"`
+}
+
+// LoadBacklog reads and parses a backlog JSON file
+func LoadBacklog(path string) (*Backlog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var backlog Backlog
+	if err := json.Unmarshal(data, &backlog); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &backlog, nil
+}
+
+// Save writes the backlog to disk
+func (b *Backlog) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backlog: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Find returns the PRD with the given ID, or nil if it is not in the backlog
+func (b *Backlog) Find(id string) *PRD {
+	for _, p := range b.Features {
+		if p != nil && p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// WithStatus returns all PRDs with the given status, in backlog order
+func (b *Backlog) WithStatus(status types.Status) []*PRD {
+	var result []*PRD
+	for _, p := range b.Features {
+		if p.Status == status {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// DependenciesMet reports whether every PRD that p depends on is complete
+func (b *Backlog) DependenciesMet(p *PRD) bool {
+	for _, id := range p.DependsOn {
+		dep := b.Find(id)
+		if dep == nil || dep.Status != types.StatusComplete {
+			return false
+		}
+	}
+	return true
+}
+
+// Next returns the PRD to execute next: in-progress work first, then the
+// first pending PRD whose dependencies are complete. Returns nil when
+// nothing is runnable.
+func (b *Backlog) Next() *PRD {
+	if runnable := b.Runnable(); len(runnable) > 0 {
+		return runnable[0]
+	}
+	return nil
+}
+
+// Runnable returns every PRD that can execute now, in the order Next picks
+// them: in-progress work first, then pending PRDs whose dependencies are complete
+func (b *Backlog) Runnable() []*PRD {
+	runnable := b.WithStatus(types.StatusInProgress)
+	for _, p := range b.WithStatus(types.StatusPending) {
+		if b.DependenciesMet(p) {
+			runnable = append(runnable, p)
+		}
+	}
+	return runnable
+}
+
+// Interrupted returns the in-progress PRDs whose last attempt never ended
+func (b *Backlog) Interrupted() []*PRD {
+	var interrupted []*PRD
+	for _, p := range b.WithStatus(types.StatusInProgress) {
+		if a := p.LastAttempt(); a != nil && a.Unfinished() {
+			interrupted = append(interrupted, p)
+		}
+	}
+	return interrupted
+}
+
+// InDependencyOrder returns every PRD after the PRDs it depends on, keeping
+// backlog order otherwise. PRDs caught in a dependency cycle come last.
+func (b *Backlog) InDependencyOrder() []*PRD {
+	placed := make(map[string]bool, len(b.Features))
+	ordered := make([]*PRD, 0, len(b.Features))
+	for len(ordered) < len(b.Features) {
+		progress := false
+		for _, p := range b.Features {
+			if placed[p.ID] || !b.depsPlaced(p, placed) {
+				continue
+			}
+			placed[p.ID] = true
+			ordered = append(ordered, p)
+			progress = true
+		}
+		if !progress {
+			break
+		}
+	}
+	for _, p := range b.Features {
+		if !placed[p.ID] {
+			ordered = append(ordered, p)
+		}
+	}
+	return ordered
+}
+
+// depsPlaced reports whether every dependency of p that exists in the
+// backlog is already placed
+func (b *Backlog) depsPlaced(p *PRD, placed map[string]bool) bool {
+	for _, id := range p.DependsOn {
+		if b.Find(id) != nil && !placed[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// CountByStatus returns how many PRDs have the given status
+func (b *Backlog) CountByStatus(status types.Status) int {
+	return len(b.WithStatus(status))
+}
+
+// Add validates p and appends it to the backlog
+func (b *Backlog) Add(p *PRD) error {
+	return b.AddAll([]*PRD{p})[p.ID]
+}
+
+// AddAll validates prds as a group, so they may depend on each other in any
+// order, and appends the valid ones. A PRD depending on a rejected one is
+// rejected too. Returns the validation errors of rejected PRDs by ID.
+func (b *Backlog) AddAll(prds []*PRD) map[string]error {
+	rejected := make(map[string]error)
+	for changed := true; changed; {
+		changed = false
+		group := &Backlog{Features: append([]*PRD{}, b.Features...)}
+		for _, p := range prds {
+			if rejected[p.ID] == nil {
+				group.Features = append(group.Features, p)
+			}
+		}
+		for _, p := range prds {
+			if rejected[p.ID] != nil {
+				continue
+			}
+			errs := p.ValidateWithDetails()
+			if b.Find(p.ID) != nil || len(group.WithID(p.ID)) > 1 {
+				errs.Add("id", "unique PRD ID", p.ID, fmt.Sprintf("Another PRD already uses ID %q", p.ID))
+			}
+			errs.Merge("", group.checkDependencies(p))
+			if errs.HasErrors() {
+				rejected[p.ID] = errs
+				changed = true
+			}
+		}
+	}
+
+	for _, p := range prds {
+		if rejected[p.ID] == nil {
+			b.Features = append(b.Features, p)
+		}
+	}
+	return rejected
+}
+
+// WithID returns every PRD using id; more than one means the backlog is invalid
+func (b *Backlog) WithID(id string) []*PRD {
+	var result []*PRD
+	for _, p := range b.Features {
+		if p != nil && p.ID == id {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Replace validates p and swaps it in for the PRD with the same ID
+func (b *Backlog) Replace(p *PRD) error {
+	for i, existing := range b.Features {
+		if existing == nil || existing.ID != p.ID {
+			continue
+		}
+		errs := p.ValidateWithDetails()
+		errs.Merge("", b.checkDependencies(p))
+		if errs.HasErrors() {
+			return errs
+		}
+		b.Features[i] = p
+		return nil
+	}
+	return fmt.Errorf("PRD %s not found in backlog", p.ID)
+}
+
+// RestorePRD puts the record for id from before, a snapshot of the backlog
+// file, back into the backlog at path and keeps every other record as it is
+// now, so what other workers saved in the meantime survives. Other records
+// that no longer parse or validate since the snapshot are put back from it
+// too. Only a file that isn't a JSON object gets the whole snapshot back.
+func RestorePRD(path string, before []byte, id string) error {
+	var snapshot Backlog
+	if err := json.Unmarshal(before, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse backlog snapshot: %w", err)
+	}
+
+	var current struct {
+		Features []json.RawMessage `json:"features"`
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &current)
+	}
+	if err != nil {
+		return restoreFile(path, before)
+	}
+
+	merged := &Backlog{Features: []*PRD{}}
+	restored := false
+	for _, raw := range current.Features {
+		p := &PRD{}
+		parseErr := json.Unmarshal(raw, p)
+		if parseErr != nil {
+			var header struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(raw, &header)
+			p = &PRD{ID: header.ID}
+		}
+
+		old := snapshot.Find(p.ID)
+		switch {
+		case p.ID == id:
+			if old != nil && !restored {
+				merged.Features = append(merged.Features, old)
+				restored = true
+			}
+		case parseErr == nil && (unchanged(p, old) || !p.ValidateWithDetails().HasErrors()):
+			merged.Features = append(merged.Features, p)
+		case old != nil:
+			merged.Features = append(merged.Features, old)
+		}
+	}
+	if old := snapshot.Find(id); old != nil && !restored {
+		merged.Features = append(merged.Features, old)
+	}
+	return merged.Save(path)
+}
+
+// restoreFile writes a snapshot back whole, removing the file if it did not
+// exist when the snapshot was taken
+func restoreFile(path string, before []byte) error {
+	if before == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := utils.WriteFileAtomic(path, before, 0644); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+	return nil
+}
+
+// Remove deletes the PRD with the given ID from the backlog
+func (b *Backlog) Remove(id string) error {
+	for i, p := range b.Features {
+		if p != nil && p.ID == id {
+			b.Features = append(b.Features[:i], b.Features[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("PRD %s not found in backlog", id)
+}
+
+// Dependents returns the IDs of PRDs that depend on id
+func (b *Backlog) Dependents(id string) []string {
+	var ids []string
+	for _, p := range b.Features {
+		if p != nil && contains(p.DependsOn, id) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+// checkDependencies reports dependencies of p that are missing from the
+// backlog or point at p itself
+func (b *Backlog) checkDependencies(p *PRD) *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+	for i, dep := range p.DependsOn {
+		field := fmt.Sprintf("depends_on[%d]", i)
+		switch {
+		case dep == p.ID:
+			errs.Add(field, "another PRD's ID", dep, "A PRD cannot depend on itself")
+		case b.Find(dep) == nil:
+			errs.Add(field, "ID of a PRD in the backlog", dep, "Remove the dependency or add the PRD it refers to")
+		}
+	}
+	return errs
+}
+
+// BacklogDiff lists the PRD IDs that differ between two versions of a backlog
+type BacklogDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// IsEmpty reports whether the two versions hold the same PRDs
+func (d BacklogDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// DiffBacklogs compares two versions of a backlog by PRD ID. A nil before
+// counts every PRD in after as added.
+func DiffBacklogs(before, after *Backlog) BacklogDiff {
+	if before == nil {
+		before = &Backlog{}
+	}
+	var diff BacklogDiff
+	for _, p := range after.Features {
+		if p == nil {
+			continue
+		}
+		switch previous := before.Find(p.ID); {
+		case previous == nil:
+			diff.Added = append(diff.Added, p.ID)
+		case !unchanged(p, previous):
+			diff.Changed = append(diff.Changed, p.ID)
+		}
+	}
+	for _, p := range before.Features {
+		if p != nil && after.Find(p.ID) == nil {
+			diff.Removed = append(diff.Removed, p.ID)
+		}
+	}
+	return diff
+}
+
+// ValidateWithDetails validates every PRD in the backlog and checks for duplicate IDs
+func (b *Backlog) ValidateWithDetails() *types.ValidationErrors {
+	return b.validateChanged(nil)
+}
+
+// validateChanged validates PRDs that are new or differ from their
+// counterpart in before. A nil before validates every PRD.
+func (b *Backlog) validateChanged(before *Backlog) *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+	seen := make(map[string]bool)
+
+	for i, p := range b.Features {
+		field := fmt.Sprintf("features[%d]", i)
+		if p == nil {
+			errs.Add(field, "PRD object", nil, "Remove the null entry from features")
+			continue
+		}
+		if p.ID != "" && seen[p.ID] {
+			errs.Add(field+".id", "unique PRD ID", p.ID, fmt.Sprintf("Another PRD already uses ID %q; give this one a different ID", p.ID))
+		}
+		seen[p.ID] = true
+
+		if before != nil && unchanged(p, before.Find(p.ID)) {
+			continue
+		}
+		errs.Merge(field, p.ValidateWithDetails())
+	}
+
+	return errs
+}
+
+// ValidateBacklogFile parses and validates a backlog file on disk. When
+// before holds the file's previous contents, only PRDs that were added or
+// modified since then are validated, so legacy entries don't block a run.
+func ValidateBacklogFile(path string, before []byte) *types.ValidationErrors {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		errs := &types.ValidationErrors{}
+		errs.Add("(file)", "readable file", path, err.Error())
+		return errs
+	}
+
+	var backlog Backlog
+	if err := json.Unmarshal(data, &backlog); err != nil {
+		return syntaxError(err)
+	}
+
+	var previous *Backlog
+	if before != nil {
+		previous = &Backlog{}
+		if err := json.Unmarshal(before, previous); err != nil {
+			previous = nil
+		}
+	}
+
+	return backlog.validateChanged(previous)
+}
+
+// unchanged reports whether two PRDs serialize identically
+func unchanged(a, b *PRD) bool {
+	if b == nil {
+		return false
+	}
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(left) == string(right)
+}
+
+// syntaxError converts a JSON decoding error into a validation error
+func syntaxError(err error) *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		errs.Add("(file)", "valid JSON", fmt.Sprintf("syntax error at byte %d", syntaxErr.Offset), "Fix the JSON syntax: "+err.Error())
+	case errors.As(err, &typeErr):
+		errs.Add(typeErr.Field, typeErr.Type.String(), typeErr.Value, "Change the value to the expected type")
+	default:
+		errs.Add("(file)", "valid JSON", nil, err.Error())
+	}
+	return errs
+}
@@ -0,0 +1,251 @@
+package prd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestValidateBacklogFile(t *testing.T) {
+	legacy := `{"features": [{"id": "legacy", "status": "pending"}]}`
+
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		wantErr bool
+	}{
+		{"unchanged legacy PRD is not validated", legacy, legacy, false},
+		{"modified legacy PRD is validated", legacy, `{"features": [{"id": "legacy", "status": "in_progress"}]}`, true},
+		{"new invalid PRD", `{"features": []}`, legacy, true},
+		{"syntax error", legacy, `{"features": [`, true},
+		{"duplicate ID", legacy, `{"features": [{"id": "legacy", "status": "pending"}, {"id": "legacy", "status": "pending"}]}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prd.json")
+			if err := os.WriteFile(path, []byte(tt.after), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := ValidateBacklogFile(path, []byte(tt.before))
+			if errs.HasErrors() != tt.wantErr {
+				t.Errorf("HasErrors() = %v, want %v: %s", errs.HasErrors(), tt.wantErr, errs.Error())
+			}
+		})
+	}
+}
+
+func TestDiffBacklogs(t *testing.T) {
+	kept, edited, dropped := NewPRD("Kept"), NewPRD("Edited"), NewPRD("Dropped")
+	before := &Backlog{Features: []*PRD{kept, edited, dropped}}
+
+	editedAfter := *edited
+	editedAfter.Description = "now with details"
+	added := NewPRD("Added")
+	after := &Backlog{Features: []*PRD{kept, &editedAfter, added}}
+
+	diff := DiffBacklogs(before, after)
+	if len(diff.Added) != 1 || diff.Added[0] != added.ID {
+		t.Errorf("Added = %v, want [%s]", diff.Added, added.ID)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0] != edited.ID {
+		t.Errorf("Changed = %v, want [%s]", diff.Changed, edited.ID)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != dropped.ID {
+		t.Errorf("Removed = %v, want [%s]", diff.Removed, dropped.ID)
+	}
+	if !DiffBacklogs(before, before).IsEmpty() {
+		t.Error("identical backlogs produced a diff")
+	}
+}
+
+func TestBacklogAdd(t *testing.T) {
+	existing := validChild("Existing")
+
+	selfDep := validChild("Self")
+	selfDep.DependsOn = []string{selfDep.ID}
+	missingDep := validChild("Missing dep")
+	missingDep.DependsOn = []string{"nope"}
+	withDep := validChild("With dep")
+	withDep.DependsOn = []string{existing.ID}
+	duplicate := validChild("Duplicate")
+	duplicate.ID = existing.ID
+
+	tests := []struct {
+		name    string
+		prd     *PRD
+		wantErr bool
+	}{
+		{"valid", validChild("Fresh"), false},
+		{"known dependency", withDep, false},
+		{"invalid", NewPRD("No steps"), true},
+		{"duplicate ID", duplicate, true},
+		{"self dependency", selfDep, true},
+		{"missing dependency", missingDep, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backlog := &Backlog{Features: []*PRD{existing}}
+			err := backlog.Add(tt.prd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := map[bool]int{false: 2, true: 1}[tt.wantErr]; len(backlog.Features) != want {
+				t.Errorf("backlog has %d PRDs, want %d", len(backlog.Features), want)
+			}
+		})
+	}
+}
+
+func TestBacklogRemove(t *testing.T) {
+	base, dependent := validChild("Base"), validChild("Dependent")
+	dependent.DependsOn = []string{base.ID}
+	backlog := &Backlog{Features: []*PRD{base, dependent}}
+
+	if got := backlog.Dependents(base.ID); len(got) != 1 || got[0] != dependent.ID {
+		t.Errorf("Dependents() = %v, want [%s]", got, dependent.ID)
+	}
+	if err := backlog.Remove(base.ID); err != nil {
+		t.Fatal(err)
+	}
+	if backlog.Find(base.ID) != nil || len(backlog.Features) != 1 {
+		t.Errorf("PRD not removed: %v", backlog.Features)
+	}
+	if err := backlog.Remove(base.ID); err == nil {
+		t.Error("removing a missing PRD succeeded")
+	}
+}
+
+func TestBacklogRunnable(t *testing.T) {
+	active := &PRD{ID: "active", Status: types.StatusInProgress}
+	backlog := &Backlog{Features: []*PRD{
+		{ID: "done", Status: types.StatusComplete},
+		{ID: "free", Status: types.StatusPending},
+		{ID: "waiting", Status: types.StatusPending, DependsOn: []string{"free"}},
+		active,
+		{ID: "unblocked", Status: types.StatusPending, DependsOn: []string{"done"}},
+	}}
+
+	var got []string
+	for _, p := range backlog.Runnable() {
+		got = append(got, p.ID)
+	}
+	if want := []string{"active", "free", "unblocked"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Runnable() = %v, want %v", got, want)
+	}
+	if next := backlog.Next(); next != active {
+		t.Errorf("Next() = %v, want %s", next, active.ID)
+	}
+}
+
+func TestBacklogInDependencyOrder(t *testing.T) {
+	backlog := &Backlog{Features: []*PRD{
+		{ID: "ui", DependsOn: []string{"api"}},
+		{ID: "api", DependsOn: []string{"db"}},
+		{ID: "docs"},
+		{ID: "db", DependsOn: []string{"gone"}},
+		{ID: "loop-a", DependsOn: []string{"loop-b"}},
+		{ID: "loop-b", DependsOn: []string{"loop-a"}},
+	}}
+
+	var got []string
+	for _, p := range backlog.InDependencyOrder() {
+		got = append(got, p.ID)
+	}
+	if want := []string{"docs", "db", "api", "ui", "loop-a", "loop-b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InDependencyOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestBacklogInterrupted(t *testing.T) {
+	started := time.Now()
+	backlog := &Backlog{Features: []*PRD{
+		{ID: "killed", Status: types.StatusInProgress, Attempts: []Attempt{{Iteration: 1, StartedAt: started}}},
+		{ID: "between-iterations", Status: types.StatusInProgress, Attempts: []Attempt{{Iteration: 1, StartedAt: started, EndedAt: started}}},
+		{ID: "never-started", Status: types.StatusInProgress},
+		{ID: "blocked", Status: types.StatusBlocked, Attempts: []Attempt{{Iteration: 1, StartedAt: started}}},
+	}}
+
+	got := backlog.Interrupted()
+	if len(got) != 1 || got[0].ID != "killed" {
+		t.Errorf("Interrupted() = %v, want [killed]", got)
+	}
+}
+
+func TestRestorePRD(t *testing.T) {
+	mine, other := validChild("Mine"), validChild("Other")
+	before, err := json.Marshal(&Backlog{Features: []*PRD{mine, other}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another worker completed its PRD and added one; this PRD's record was mangled
+	done := *other
+	done.Status = types.StatusComplete
+	added := validChild("Added")
+	mangled := *mine
+	mangled.Title = ""
+	broken := *other
+	broken.Steps = nil
+
+	tests := []struct {
+		name      string
+		current   string
+		wantOther types.Status
+		wantAdded bool
+	}{
+		{"keeps other records", mustJSON(t, &Backlog{Features: []*PRD{&mangled, &done, added}}), types.StatusComplete, true},
+		{"puts back a deleted record", mustJSON(t, &Backlog{Features: []*PRD{&done}}), types.StatusComplete, false},
+		{"invalid other record comes from the snapshot", mustJSON(t, &Backlog{Features: []*PRD{&mangled, &broken, added}}), other.Status, true},
+		{
+			"unparseable other record comes from the snapshot",
+			`{"features": [` + mustJSON(t, &mangled) + `, {"id": "` + other.ID + `", "status": 5}, ` + mustJSON(t, added) + `]}`,
+			other.Status, true,
+		},
+		{"unparseable file gets the snapshot", `{"features": [`, other.Status, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prd.json")
+			if err := os.WriteFile(path, []byte(tt.current), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := RestorePRD(path, before, mine.ID); err != nil {
+				t.Fatalf("RestorePRD() error = %v", err)
+			}
+			backlog, err := LoadBacklog(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := backlog.Find(mine.ID); got == nil || got.Title != mine.Title {
+				t.Errorf("restored record = %+v, want title %q", got, mine.Title)
+			}
+			if got := backlog.Find(other.ID); got == nil || got.Status != tt.wantOther || len(got.Steps) == 0 {
+				t.Errorf("other record = %+v, want a valid record with status %s", got, tt.wantOther)
+			}
+			if got := backlog.Find(added.ID) != nil; got != tt.wantAdded {
+				t.Errorf("added record kept = %v, want %v", got, tt.wantAdded)
+			}
+			if errs := ValidateBacklogFile(path, nil); errs.HasErrors() {
+				t.Errorf("restored backlog is invalid: %s", errs.Error())
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,139 @@
+package prd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/daydemir/ralph/internal/utils"
+)
+
+// CompactOptions control which progress entries are moved to the archive
+type CompactOptions struct {
+	Keep   int       // most recent entries that always stay in progress.json
+	Before time.Time // only entries older than this are archived; zero archives regardless of age
+}
+
+// CompactResult describes what Compact changed
+type CompactResult struct {
+	Archived        []ProgressEntry
+	MergedPatterns  int
+	MergedLearnings int
+}
+
+// Changed reports whether compaction modified the progress
+func (r *CompactResult) Changed() bool {
+	return len(r.Archived) > 0 || r.MergedPatterns > 0 || r.MergedLearnings > 0
+}
+
+// Archive is a file of entries moved out of progress.json
+type Archive struct {
+	ArchivedAt time.Time       `json:"archived_at"`
+	Entries    []ProgressEntry `json:"entries"`
+}
+
+// Compact removes old entries (returned for archiving) and merges duplicate
+// patterns and learnings. Learnings and patterns are never archived.
+func (p *Progress) Compact(opts CompactOptions) *CompactResult {
+	result := &CompactResult{}
+
+	cutoff := len(p.Entries) - opts.Keep
+	var kept []ProgressEntry
+	for i, e := range p.Entries {
+		if i < cutoff && (opts.Before.IsZero() || e.Timestamp.Before(opts.Before)) {
+			result.Archived = append(result.Archived, e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(result.Archived) > 0 {
+		if kept == nil {
+			kept = []ProgressEntry{}
+		}
+		p.Entries = kept
+		p.ArchivedEntries += len(result.Archived)
+	}
+
+	result.MergedPatterns = p.mergePatterns()
+	result.MergedLearnings = p.mergeLearnings()
+	return result
+}
+
+// mergePatterns folds duplicate patterns into the first occurrence, keeping its ID
+func (p *Progress) mergePatterns() int {
+	var merged []Pattern
+	for _, pat := range p.Patterns {
+		duplicate := false
+		for i := range merged {
+			existing := &merged[i]
+			if sameKind(string(existing.Type), string(pat.Type)) &&
+				similar(existing.Name+" "+existing.Description, pat.Name+" "+pat.Description) {
+				existing.Examples = union(existing.Examples, pat.Examples)
+				if existing.Type == "" {
+					existing.Type = pat.Type
+				}
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, pat)
+		}
+	}
+	removed := len(p.Patterns) - len(merged)
+	if removed > 0 {
+		p.Patterns = merged
+	}
+	return removed
+}
+
+// mergeLearnings folds duplicate learnings into the first occurrence, keeping
+// its ID and summing usage counts
+func (p *Progress) mergeLearnings() int {
+	var merged []Learning
+	for _, l := range p.Learnings {
+		duplicate := false
+		for i := range merged {
+			existing := &merged[i]
+			if sameKind(string(existing.Type), string(l.Type)) && similar(existing.Content, l.Content) {
+				existing.AppliesTo = union(existing.AppliesTo, l.AppliesTo)
+				existing.TimesReferenced += l.TimesReferenced
+				existing.StillValid = existing.StillValid || l.StillValid
+				if existing.Type == "" {
+					existing.Type = l.Type
+				}
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, l)
+		}
+	}
+	removed := len(p.Learnings) - len(merged)
+	if removed > 0 {
+		p.Learnings = merged
+	}
+	return removed
+}
+
+// WriteArchive saves archived entries to a new timestamped file in dir and
+// returns its path
+func WriteArchive(dir string, entries []ProgressEntry) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("progress-%s.json", now.Format("20060102-150405")))
+	data, err := json.MarshalIndent(Archive{ArchivedAt: now, Entries: entries}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal archive: %w", err)
+	}
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,60 @@
+package prd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestCompact(t *testing.T) {
+	now := time.Now()
+	newProgress := func() *Progress {
+		p := NewProgress()
+		for i, age := range []time.Duration{90, 60, 30, 1} {
+			p.Entries = append(p.Entries, ProgressEntry{
+				ID: EntryID("auth", i+1), PRDID: "auth", Iteration: i + 1,
+				Status: types.ProgressPartial, Timestamp: now.Add(-age * 24 * time.Hour),
+			})
+		}
+		p.Patterns = []Pattern{
+			{ID: "pattern-0001", Name: "Services in internal/services", Examples: []string{"a.go"}},
+			{ID: "pattern-0002", Name: "services in internal/services.", Examples: []string{"b.go"}},
+		}
+		p.Learnings = []Learning{
+			{ID: "learning-0001", Content: "Run make gen", TimesReferenced: 2, StillValid: true},
+			{ID: "learning-0002", Content: "run make gen", TimesReferenced: 3},
+		}
+		return p
+	}
+
+	tests := []struct {
+		name         string
+		opts         CompactOptions
+		wantArchived int
+	}{
+		{"keep two", CompactOptions{Keep: 2}, 2},
+		{"keep more than exist", CompactOptions{Keep: 10}, 0},
+		{"keep one, older than 45 days", CompactOptions{Keep: 1, Before: now.Add(-45 * 24 * time.Hour)}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProgress()
+			result := p.Compact(tt.opts)
+
+			if len(result.Archived) != tt.wantArchived || len(p.Entries) != 4-tt.wantArchived {
+				t.Errorf("archived %d, kept %d; want %d archived", len(result.Archived), len(p.Entries), tt.wantArchived)
+			}
+			if p.ArchivedEntries != tt.wantArchived {
+				t.Errorf("ArchivedEntries = %d, want %d", p.ArchivedEntries, tt.wantArchived)
+			}
+			if result.MergedPatterns != 1 || len(p.Patterns[0].Examples) != 2 {
+				t.Errorf("patterns not merged: %+v", p.Patterns)
+			}
+			if result.MergedLearnings != 1 || p.Learnings[0].TimesReferenced != 5 || p.Learnings[0].ID != "learning-0001" {
+				t.Errorf("learnings not merged: %+v", p.Learnings)
+			}
+		})
+	}
+}
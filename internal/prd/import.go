@@ -0,0 +1,281 @@
+package prd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Imported is a PRD parsed from an external spec, with its dependencies
+// still expressed as references into the same import
+type Imported struct {
+	PRD       *PRD
+	Ref       string   // how other items refer to this one: "#3", "#42" or "ENG-42"
+	DependsOn []string // references found in "depends on ..." text
+}
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	checklistPattern = regexp.MustCompile(`^\s*[-*+]\s+\[[ xX]\]\s+(.+)$`)
+	numberedPattern  = regexp.MustCompile(`^\s*\d+[.)]\s+(.+)$`)
+	dependsPattern   = regexp.MustCompile(`(?i)depends\s+on:?\s+((?:#\d+|[A-Z][A-Z0-9]*-\d+)(?:\s*(?:,|and|&)\s*(?:#\d+|[A-Z][A-Z0-9]*-\d+))*)`)
+	referencePattern = regexp.MustCompile(`#\d+|[A-Z][A-Z0-9]*-\d+`)
+)
+
+// ParseMarkdown turns a Markdown spec into PRDs. Each heading at the spec's
+// PRD level starts a PRD: level 1 when the file has several H1s, otherwise
+// level 2. Below it, checklist items become acceptance criteria, numbered
+// items become steps, and other text becomes the description. PRDs are
+// referenced by position, so "depends on #2" means the second PRD.
+func ParseMarkdown(data []byte) []Imported {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	level := prdHeadingLevel(lines)
+
+	var items []Imported
+	var body []string
+	flush := func() {
+		if len(items) > 0 {
+			last := &items[len(items)-1]
+			last.DependsOn = parseBody(last.PRD, body)
+		}
+		body = nil
+	}
+
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if match := headingPattern.FindStringSubmatch(line); match != nil && !inFence && len(match[1]) == level {
+			flush()
+			items = append(items, Imported{PRD: NewPRD(match[2]), Ref: "#" + strconv.Itoa(len(items)+1)})
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return items
+}
+
+// prdHeadingLevel picks the heading level that separates PRDs
+func prdHeadingLevel(lines []string) int {
+	counts := make(map[int]int)
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if match := headingPattern.FindStringSubmatch(line); match != nil && !inFence {
+			counts[len(match[1])]++
+		}
+	}
+	if counts[1] > 1 || counts[2] == 0 {
+		return 1
+	}
+	return 2
+}
+
+// parseBody fills in a PRD from the lines under its heading and returns
+// the references it depends on
+func parseBody(p *PRD, lines []string) []string {
+	var description []string
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			description = append(description, line)
+			continue
+		}
+		if inFence {
+			description = append(description, line)
+			continue
+		}
+		if match := checklistPattern.FindStringSubmatch(line); match != nil {
+			p.AcceptanceCriteria = append(p.AcceptanceCriteria, strings.TrimSpace(match[1]))
+			continue
+		}
+		if match := numberedPattern.FindStringSubmatch(line); match != nil {
+			p.Steps = append(p.Steps, strings.TrimSpace(match[1]))
+			continue
+		}
+		// Sub-headings like "### Acceptance criteria" only group the lists below them
+		if headingPattern.MatchString(line) {
+			continue
+		}
+		description = append(description, line)
+	}
+	p.Description = collapseBlankLines(description)
+	if p.Description == "" {
+		// Terse specs often have nothing beyond the title
+		p.Description = p.Title
+	}
+
+	var refs []string
+	for _, match := range dependsPattern.FindAllStringSubmatch(strings.Join(lines, "\n"), -1) {
+		refs = union(refs, referencePattern.FindAllString(match[1], -1))
+	}
+	return refs
+}
+
+// collapseBlankLines trims the text and squeezes runs of blank lines to one
+func collapseBlankLines(lines []string) string {
+	var kept []string
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if !blank && len(kept) > 0 {
+				kept = append(kept, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// issue is the subset of a GitHub or Linear issue export that maps to a PRD
+type issue struct {
+	Number      int             `json:"number"`     // GitHub
+	Identifier  string          `json:"identifier"` // Linear, e.g. "ENG-42"
+	Title       string          `json:"title"`
+	Body        string          `json:"body"`        // GitHub
+	Description string          `json:"description"` // Linear
+	State       json.RawMessage `json:"state"`       // "OPEN" on GitHub, {"type": "started"} on Linear
+	Labels      json.RawMessage `json:"labels"`
+}
+
+// ParseIssues turns a JSON export of GitHub issues (gh issue list --json
+// number,title,body,labels,state) or Linear issues into PRDs. The export may
+// be an array, an object with an "issues" array, or a GraphQL response with
+// data.issues.nodes. Closed, completed and canceled issues are skipped.
+// Issue bodies are parsed like Markdown PRD sections.
+func ParseIssues(data []byte) ([]Imported, error) {
+	issues, err := decodeIssues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Imported
+	for _, is := range issues {
+		if is.Title == "" || issueClosed(is.State) {
+			continue
+		}
+		p := NewPRD(is.Title)
+		p.Tags = issueLabels(is.Labels)
+
+		body := is.Body
+		if body == "" {
+			body = is.Description
+		}
+		item := Imported{PRD: p, Ref: is.Identifier}
+		if is.Number != 0 {
+			item.Ref = "#" + strconv.Itoa(is.Number)
+		}
+		item.DependsOn = parseBody(p, strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n"))
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func decodeIssues(data []byte) ([]issue, error) {
+	var issues []issue
+	if err := json.Unmarshal(data, &issues); err == nil {
+		return issues, nil
+	}
+
+	var wrapped struct {
+		Issues []issue `json:"issues"`
+		Data   struct {
+			Issues struct {
+				Nodes []issue `json:"nodes"`
+			} `json:"issues"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to parse issue export: %w", err)
+	}
+	if wrapped.Issues != nil {
+		return wrapped.Issues, nil
+	}
+	if wrapped.Data.Issues.Nodes != nil {
+		return wrapped.Data.Issues.Nodes, nil
+	}
+	return nil, fmt.Errorf("failed to parse issue export: expected an array of issues")
+}
+
+// issueClosed reports whether an issue's state marks it as done
+func issueClosed(raw json.RawMessage) bool {
+	var state string
+	if err := json.Unmarshal(raw, &state); err == nil {
+		return strings.EqualFold(state, "closed")
+	}
+	var linear struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &linear); err == nil {
+		return linear.Type == "completed" || linear.Type == "canceled"
+	}
+	return false
+}
+
+// issueLabels reads label names from ["a"], [{"name": "a"}] or {"nodes": [{"name": "a"}]}
+func issueLabels(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var strs []string
+	if err := json.Unmarshal(raw, &strs); err == nil {
+		return strs
+	}
+	var names []string
+	type label struct {
+		Name string `json:"name"`
+	}
+	var labels []label
+	if err := json.Unmarshal(raw, &labels); err != nil {
+		var connection struct {
+			Nodes []label `json:"nodes"`
+		}
+		if err := json.Unmarshal(raw, &connection); err != nil {
+			return nil
+		}
+		labels = connection.Nodes
+	}
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// ResolveImports maps dependency references to the IDs of the imported
+// PRDs they name. References outside the import are returned as
+// "<prd-id>: <ref>" so the caller can report them.
+func ResolveImports(items []Imported) ([]*PRD, []string) {
+	ids := make(map[string]string, len(items))
+	for _, item := range items {
+		if item.Ref != "" {
+			ids[item.Ref] = item.PRD.ID
+		}
+	}
+
+	prds := make([]*PRD, len(items))
+	var unresolved []string
+	for i, item := range items {
+		for _, ref := range item.DependsOn {
+			id, ok := ids[ref]
+			switch {
+			case !ok:
+				unresolved = append(unresolved, item.PRD.ID+": "+ref)
+			case id != item.PRD.ID:
+				item.PRD.DependsOn = union(item.PRD.DependsOn, []string{id})
+			}
+		}
+		prds[i] = item.PRD
+	}
+	return prds, unresolved
+}
@@ -0,0 +1,130 @@
+package prd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	spec := `# Checkout
+
+Intro for the whole spec.
+
+## Cart persistence
+
+Keep carts across sessions. Depends on #2.
+
+- [ ] Cart survives logout
+- [x] Cart merges on login
+
+1. Add carts table
+2. Persist on change
+
+## Carts table
+
+### Steps
+1. Write migration
+
+` + "```" + `
+1. not a step
+` + "```"
+
+	items := ParseMarkdown([]byte(spec))
+	if len(items) != 2 {
+		t.Fatalf("got %d PRDs, want 2", len(items))
+	}
+
+	cart := items[0].PRD
+	if cart.Title != "Cart persistence" || cart.Description != "Keep carts across sessions. Depends on #2." {
+		t.Errorf("title/description = %q / %q", cart.Title, cart.Description)
+	}
+	if !reflect.DeepEqual(cart.AcceptanceCriteria, []string{"Cart survives logout", "Cart merges on login"}) {
+		t.Errorf("criteria = %v", cart.AcceptanceCriteria)
+	}
+	if !reflect.DeepEqual(cart.Steps, []string{"Add carts table", "Persist on change"}) {
+		t.Errorf("steps = %v", cart.Steps)
+	}
+	if !reflect.DeepEqual(items[0].DependsOn, []string{"#2"}) || items[1].Ref != "#2" {
+		t.Errorf("dependency refs = %v, second ref = %q", items[0].DependsOn, items[1].Ref)
+	}
+	if !reflect.DeepEqual(items[1].PRD.Steps, []string{"Write migration"}) {
+		t.Errorf("fenced list parsed as steps: %v", items[1].PRD.Steps)
+	}
+
+	prds, unresolved := ResolveImports(items)
+	if len(unresolved) != 0 || !reflect.DeepEqual(prds[0].DependsOn, []string{prds[1].ID}) {
+		t.Errorf("resolved depends_on = %v, unresolved = %v", prds[0].DependsOn, unresolved)
+	}
+}
+
+func TestParseIssues(t *testing.T) {
+	tests := []struct {
+		name     string
+		export   string
+		wantRefs []string
+		wantTags []string
+	}{
+		{
+			"github",
+			`[{"number": 12, "title": "Rate limiter", "body": "depends on #13", "labels": [{"name": "api"}], "state": "OPEN"},
+			  {"number": 13, "title": "Redis client", "state": "OPEN"},
+			  {"number": 14, "title": "Done already", "state": "CLOSED"}]`,
+			[]string{"#12", "#13"},
+			[]string{"api"},
+		},
+		{
+			"linear graphql",
+			`{"data": {"issues": {"nodes": [
+			  {"identifier": "ENG-1", "title": "Rate limiter", "description": "Depends on ENG-2", "labels": {"nodes": [{"name": "api"}]}, "state": {"type": "started"}},
+			  {"identifier": "ENG-2", "title": "Redis client", "state": {"type": "unstarted"}},
+			  {"identifier": "ENG-3", "title": "Dropped", "state": {"type": "canceled"}}]}}}`,
+			[]string{"ENG-1", "ENG-2"},
+			[]string{"api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := ParseIssues([]byte(tt.export))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var refs []string
+			for _, item := range items {
+				refs = append(refs, item.Ref)
+			}
+			if !reflect.DeepEqual(refs, tt.wantRefs) {
+				t.Errorf("refs = %v, want %v", refs, tt.wantRefs)
+			}
+			if !reflect.DeepEqual(items[0].PRD.Tags, tt.wantTags) {
+				t.Errorf("tags = %v, want %v", items[0].PRD.Tags, tt.wantTags)
+			}
+
+			prds, unresolved := ResolveImports(items)
+			if len(unresolved) != 0 || !reflect.DeepEqual(prds[0].DependsOn, []string{prds[1].ID}) {
+				t.Errorf("depends_on = %v, unresolved = %v", prds[0].DependsOn, unresolved)
+			}
+		})
+	}
+}
+
+func TestBacklogAddAll(t *testing.T) {
+	// The first PRD depends on the second, which depends on an invalid third
+	first, second, third := validChild("First"), validChild("Second"), NewPRD("Third")
+	first.DependsOn = []string{second.ID}
+	fourth := validChild("Fourth")
+	fourth.DependsOn = []string{first.ID}
+
+	backlog := &Backlog{}
+	rejected := backlog.AddAll([]*PRD{fourth, first, second})
+	if len(rejected) != 0 || len(backlog.Features) != 3 {
+		t.Fatalf("forward dependencies rejected: %v", rejected)
+	}
+
+	second.DependsOn = []string{third.ID}
+	backlog = &Backlog{}
+	rejected = backlog.AddAll([]*PRD{fourth, first, second, third})
+	if len(rejected) != 4 || len(backlog.Features) != 0 {
+		t.Errorf("rejected %d, added %d; want the invalid PRD and its dependents rejected", len(rejected), len(backlog.Features))
+	}
+}
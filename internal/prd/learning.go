@@ -0,0 +1,274 @@
+package prd
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// duplicateThreshold is the word-overlap similarity at which two learnings
+// or patterns are considered the same
+const duplicateThreshold = 0.8
+
+// AddLearning records a learning, assigning its ID and creation time. If an
+// equivalent learning already exists it is reaffirmed instead: its
+// applies_to is extended and it is marked valid again. Returns the stored
+// learning and whether it was newly added.
+func (p *Progress) AddLearning(learning Learning) (*Learning, bool) {
+	learning.Content = strings.TrimSpace(learning.Content)
+	for i := range p.Learnings {
+		existing := &p.Learnings[i]
+		if sameKind(string(existing.Type), string(learning.Type)) && similar(existing.Content, learning.Content) {
+			existing.AppliesTo = union(existing.AppliesTo, learning.AppliesTo)
+			existing.StillValid = true
+			if existing.Type == "" {
+				existing.Type = learning.Type
+			}
+			return existing, false
+		}
+	}
+
+	learning.ID = fmt.Sprintf("learning-%04d", nextSeq(len(p.Learnings), func(i int) string { return p.Learnings[i].ID }, "learning-"))
+	if learning.CreatedAt.IsZero() {
+		learning.CreatedAt = time.Now()
+	}
+	learning.StillValid = true
+	p.Learnings = append(p.Learnings, learning)
+	return &p.Learnings[len(p.Learnings)-1], true
+}
+
+// AddPattern records a codebase pattern, assigning its ID and discovery
+// time. An equivalent existing pattern absorbs the new one's examples
+// instead. Returns the stored pattern and whether it was newly added.
+func (p *Progress) AddPattern(pattern Pattern) (*Pattern, bool) {
+	pattern.Name = strings.TrimSpace(pattern.Name)
+	for i := range p.Patterns {
+		existing := &p.Patterns[i]
+		if sameKind(string(existing.Type), string(pattern.Type)) &&
+			similar(existing.Name+" "+existing.Description, pattern.Name+" "+pattern.Description) {
+			existing.Examples = union(existing.Examples, pattern.Examples)
+			if existing.Type == "" {
+				existing.Type = pattern.Type
+			}
+			return existing, false
+		}
+	}
+
+	pattern.ID = fmt.Sprintf("pattern-%04d", nextSeq(len(p.Patterns), func(i int) string { return p.Patterns[i].ID }, "pattern-"))
+	if pattern.DiscoveredAt.IsZero() {
+		pattern.DiscoveredAt = time.Now()
+	}
+	p.Patterns = append(p.Patterns, pattern)
+	return &p.Patterns[len(p.Patterns)-1], true
+}
+
+// MarkReferenced increments the usage counter of the given learnings
+func (p *Progress) MarkReferenced(ids []string) {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	for i := range p.Learnings {
+		if wanted[p.Learnings[i].ID] {
+			p.Learnings[i].TimesReferenced++
+		}
+	}
+}
+
+// RelevantLearnings ranks valid learnings by relevance to a PRD and returns
+// up to limit of them whose combined content fits within budget bytes.
+// Learnings scoped to other files or tags are skipped; unscoped learnings
+// are kept as general advice.
+func (p *Progress) RelevantLearnings(target *PRD, limit, budget int) []Learning {
+	type ranked struct {
+		learning Learning
+		score    float64
+	}
+
+	words := significantWords(target.Title + " " + target.Description)
+	var candidates []ranked
+	for _, l := range p.Learnings {
+		if !l.StillValid {
+			continue
+		}
+		scoped, score := scopeScore(l, target)
+		if scoped && score == 0 {
+			continue
+		}
+		if l.SourcePRDID == target.ID || contains(target.DependsOn, l.SourcePRDID) {
+			score += 2
+		}
+		score += math.Min(float64(overlap(significantWords(l.Content), words)), 3)
+		score += 0.5 * math.Log2(1+float64(l.TimesReferenced))
+		candidates = append(candidates, ranked{learning: l, score: score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].learning.CreatedAt.After(candidates[j].learning.CreatedAt)
+	})
+
+	var selected []Learning
+	used := 0
+	for _, c := range candidates {
+		if len(selected) >= limit {
+			break
+		}
+		size := len(c.learning.Content) + len(c.learning.Context)
+		if used+size > budget {
+			continue
+		}
+		used += size
+		selected = append(selected, c.learning)
+	}
+	return selected
+}
+
+// scopeScore reports whether the learning is scoped via applies_to and how
+// many of its scopes match the PRD's related files or tags
+func scopeScore(l Learning, target *PRD) (bool, float64) {
+	if len(l.AppliesTo) == 0 {
+		return false, 0
+	}
+	score := 0.0
+	for _, scope := range l.AppliesTo {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if containsFold(target.Tags, scope) {
+			score += 3
+			continue
+		}
+		for _, file := range target.RelatedFiles {
+			if matchesPath(scope, file) {
+				score += 3
+				break
+			}
+		}
+	}
+	return true, score
+}
+
+// matchesPath reports whether scope names file, a directory containing it,
+// its base name, or a glob matching either
+func matchesPath(scope, file string) bool {
+	scope = strings.TrimSuffix(path.Clean(scope), "/")
+	file = path.Clean(file)
+	if scope == file || strings.HasPrefix(file, scope+"/") || scope == path.Base(file) {
+		return true
+	}
+	if ok, _ := path.Match(scope, file); ok {
+		return true
+	}
+	ok, _ := path.Match(scope, path.Base(file))
+	return ok
+}
+
+// similar reports whether two texts say the same thing after normalization
+func similar(a, b string) bool {
+	left, right := normalize(a), normalize(b)
+	if left == right {
+		return true
+	}
+	return jaccard(strings.Fields(left), strings.Fields(right)) >= duplicateThreshold
+}
+
+// normalize lowercases text, drops punctuation and collapses whitespace
+func normalize(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '/', r == '.', r == '_', r == '-':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(' ')
+		}
+	}
+	fields := strings.Fields(sb.String())
+	for i, f := range fields {
+		fields[i] = strings.Trim(f, ".-")
+	}
+	return strings.Join(fields, " ")
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	setA := make(map[string]bool, len(a))
+	for _, w := range a {
+		setA[w] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, w := range b {
+		setB[w] = true
+	}
+	shared := 0
+	for w := range setA {
+		if setB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
+
+// significantWords returns the set of normalized words longer than three letters
+func significantWords(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(normalize(s)) {
+		if len(w) > 3 {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+func overlap(a, b map[string]bool) int {
+	n := 0
+	for w := range a {
+		if b[w] {
+			n++
+		}
+	}
+	return n
+}
+
+// sameKind treats an unset type as compatible with any type
+func sameKind(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+func union(a, b []string) []string {
+	result := append([]string{}, a...)
+	for _, s := range b {
+		if !contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
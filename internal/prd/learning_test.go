@@ -0,0 +1,101 @@
+package prd
+
+import (
+	"testing"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestAddLearningDedupe(t *testing.T) {
+	tests := []struct {
+		name    string
+		first   Learning
+		second  Learning
+		wantNew bool
+	}{
+		{
+			name:    "punctuation and case",
+			first:   Learning{Content: "Run `make generate` before go build."},
+			second:  Learning{Content: "run make generate before go build"},
+			wantNew: false,
+		},
+		{
+			name:    "near duplicate wording",
+			first:   Learning{Content: "Integration tests need the postgres container running on port 5432"},
+			second:  Learning{Content: "Integration tests need the postgres container running on port 5432 first"},
+			wantNew: false,
+		},
+		{
+			name:    "different learning",
+			first:   Learning{Content: "Use presigned URLs for uploads"},
+			second:  Learning{Content: "Migrations run automatically on startup"},
+			wantNew: true,
+		},
+		{
+			name:    "same text different type",
+			first:   Learning{Type: types.LearningBuildCommand, Content: "use make"},
+			second:  Learning{Type: types.LearningTestPattern, Content: "use make"},
+			wantNew: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProgress()
+			p.AddLearning(tt.first)
+			tt.second.AppliesTo = []string{"internal/db"}
+			stored, isNew := p.AddLearning(tt.second)
+
+			if isNew != tt.wantNew {
+				t.Fatalf("isNew = %v, want %v", isNew, tt.wantNew)
+			}
+			if !isNew && (len(p.Learnings) != 1 || len(stored.AppliesTo) != 1) {
+				t.Errorf("duplicate not merged: %+v", p.Learnings)
+			}
+		})
+	}
+}
+
+func TestRelevantLearnings(t *testing.T) {
+	p := NewProgress()
+	p.AddLearning(Learning{Content: "Handlers must return typed errors", AppliesTo: []string{"internal/handlers"}})
+	p.AddLearning(Learning{Content: "Billing webhooks are idempotent", AppliesTo: []string{"billing"}})
+	p.AddLearning(Learning{Content: "Run go generate after editing protobufs"})
+	p.AddLearning(Learning{Content: "Tests in *_integration_test.go need docker", AppliesTo: []string{"*_test.go"}})
+	stale, _ := p.AddLearning(Learning{Content: "The old auth package is deprecated"})
+	stale.StillValid = false
+
+	target := &PRD{
+		ID:           "auth-login",
+		Title:        "Login handler",
+		RelatedFiles: []string{"internal/handlers/login.go", "internal/handlers/login_test.go"},
+		Tags:         []string{"auth"},
+	}
+
+	got := p.RelevantLearnings(target, 10, 4000)
+	contents := make(map[string]bool)
+	for _, l := range got {
+		contents[l.Content] = true
+	}
+
+	for _, want := range []string{"Handlers must return typed errors", "Run go generate after editing protobufs", "Tests in *_integration_test.go need docker"} {
+		if !contents[want] {
+			t.Errorf("missing relevant learning %q", want)
+		}
+	}
+	for _, unwanted := range []string{"Billing webhooks are idempotent", "The old auth package is deprecated"} {
+		if contents[unwanted] {
+			t.Errorf("included irrelevant learning %q", unwanted)
+		}
+	}
+	if len(got) == 0 || len(got[len(got)-1].AppliesTo) != 0 {
+		t.Errorf("scoped matches should rank above general learnings, got %+v", got)
+	}
+
+	if limited := p.RelevantLearnings(target, 1, 4000); len(limited) != 1 {
+		t.Errorf("limit 1 returned %d learnings", len(limited))
+	}
+	if budgeted := p.RelevantLearnings(target, 10, 40); len(budgeted) != 1 {
+		t.Errorf("budget 40 returned %d learnings", len(budgeted))
+	}
+}
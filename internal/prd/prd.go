@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/utils"
 )
 
 // PRD represents a single product requirement document
@@ -27,13 +28,76 @@ type PRD struct {
 
 	DependsOn    []string `json:"depends_on,omitempty"`
 	RelatedFiles []string `json:"related_files,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
 
 	Verification Verification `json:"verification"`
 
-	CurrentIteration int `json:"current_iteration"`
-	MaxIterations    int `json:"max_iterations"`
+	CurrentIteration int                    `json:"current_iteration"`
+	MaxIterations    int                    `json:"max_iterations"`
+	Escalation       types.EscalationPolicy `json:"escalation,omitempty"` // overrides build.escalation for this PRD
+	Model            string                 `json:"model,omitempty"`      // executor model override, set when escalated to a stronger model
+
+	Parent string `json:"parent,omitempty"` // PRD this one was split from
 
 	Attempts []Attempt `json:"attempts,omitempty"`
+
+	UnblockWhen []UnblockCondition `json:"unblock_when,omitempty"` // all must hold for a blocked PRD to return to pending
+}
+
+// UnblockCondition is a machine-checkable condition for leaving blocked
+// status. Exactly one field is set.
+type UnblockCondition struct {
+	Command     string `json:"command,omitempty"`      // shell command that must exit 0, run from the workspace root
+	FileExists  string `json:"file_exists,omitempty"`  // path that must exist, relative to the workspace root
+	PRDComplete string `json:"prd_complete,omitempty"` // ID of a PRD that must be complete
+}
+
+// Unblock condition kinds as written in signals and CLI flags
+const (
+	UnblockCommand     = "command"
+	UnblockFileExists  = "file_exists"
+	UnblockPRDComplete = "prd_complete"
+)
+
+// NewUnblockCondition builds a condition from a kind and its value
+func NewUnblockCondition(kind, value string) (UnblockCondition, error) {
+	if value == "" {
+		return UnblockCondition{}, fmt.Errorf("unblock condition %s: value is required", kind)
+	}
+	switch kind {
+	case UnblockCommand:
+		return UnblockCondition{Command: value}, nil
+	case UnblockFileExists:
+		return UnblockCondition{FileExists: value}, nil
+	case UnblockPRDComplete:
+		return UnblockCondition{PRDComplete: value}, nil
+	}
+	return UnblockCondition{}, fmt.Errorf("unknown unblock condition %q, must be one of: %s, %s, %s",
+		kind, UnblockCommand, UnblockFileExists, UnblockPRDComplete)
+}
+
+// String describes the condition, e.g. "file_exists: .env"
+func (c UnblockCondition) String() string {
+	switch {
+	case c.Command != "":
+		return UnblockCommand + ": " + c.Command
+	case c.FileExists != "":
+		return UnblockFileExists + ": " + c.FileExists
+	case c.PRDComplete != "":
+		return UnblockPRDComplete + ": " + c.PRDComplete
+	}
+	return "(empty)"
+}
+
+// fieldsSet counts how many of the condition's fields are set
+func (c UnblockCondition) fieldsSet() int {
+	n := 0
+	for _, v := range []string{c.Command, c.FileExists, c.PRDComplete} {
+		if v != "" {
+			n++
+		}
+	}
+	return n
 }
 
 // Verification holds the verification commands for a PRD
@@ -44,17 +108,47 @@ type Verification struct {
 	Custom    []string `json:"custom,omitempty"`
 }
 
+// IsEmpty returns true if no verification commands are defined
+func (v Verification) IsEmpty() bool {
+	return len(v.Tests) == 0 && len(v.Build) == 0 && len(v.TypeCheck) == 0 && len(v.Custom) == 0
+}
+
 // Attempt represents a single execution attempt of the PRD
 type Attempt struct {
-	Iteration      int       `json:"iteration"`
-	StartedAt      time.Time `json:"started_at"`
-	EndedAt        time.Time `json:"ended_at"`
-	Outcome        string    `json:"outcome"` // partial, complete, blocked, no_progress
-	StepsCompleted []string  `json:"steps_completed,omitempty"`
-	StepsRemaining []string  `json:"steps_remaining,omitempty"`
-	Blocker        string    `json:"blocker,omitempty"`
-	Observations   []string  `json:"observations,omitempty"`
-	EvidencePath   string    `json:"evidence_path,omitempty"`
+	Iteration      int                   `json:"iteration"`
+	StartedAt      time.Time             `json:"started_at"`
+	EndedAt        time.Time             `json:"ended_at,omitzero"` // zero while the attempt is running
+	Outcome        types.Outcome         `json:"outcome,omitempty"` // empty while the attempt is running
+	StepsCompleted []string              `json:"steps_completed,omitempty"`
+	StepsRemaining []string              `json:"steps_remaining,omitempty"`
+	Blocker        string                `json:"blocker,omitempty"`
+	Observations   []string              `json:"observations,omitempty"`
+	EvidencePath   string                `json:"evidence_path,omitempty"`
+	BaseCommit     string                `json:"base_commit,omitempty"` // HEAD when the attempt started
+	Commits        []string              `json:"commits,omitempty"`     // commits made during the attempt, oldest first
+	Patch          string                `json:"patch,omitempty"`       // where the changes of a rolled-back attempt were saved
+	Recovery       types.InterruptAction `json:"recovery,omitempty"`    // what was done with the attempt after it was interrupted
+	Tokens         int                   `json:"tokens,omitempty"`      // input plus output tokens of the executor session
+	CostUSD        float64               `json:"cost_usd,omitempty"`    // executor session cost, when Claude reports it
+	Review         *Review               `json:"review,omitempty"`
+}
+
+// Review records the independent check of an executor's completion claim
+type Review struct {
+	ReviewedAt         time.Time         `json:"reviewed_at"`
+	Passed             bool              `json:"passed"`
+	VerificationPassed bool              `json:"verification_passed"`
+	NewFailures        []string          `json:"new_failures,omitempty"` // regressions relative to the pre-iteration baseline
+	FixedFailures      []string          `json:"fixed_failures,omitempty"`
+	Criteria           []CriterionResult `json:"criteria,omitempty"`
+	Summary            string            `json:"summary,omitempty"`
+}
+
+// CriterionResult is the reviewer's verdict on a single acceptance criterion
+type CriterionResult struct {
+	Criterion string `json:"criterion"`
+	Met       bool   `json:"met"`
+	Reason    string `json:"reason,omitempty"`
 }
 
 // Load reads and parses a PRD JSON file
@@ -79,13 +173,17 @@ func (p *PRD) Save(path string) error {
 		return fmt.Errorf("failed to marshal PRD: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write %s: %w", path, err)
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// DefaultMaxIterations is the iteration budget of a new PRD, and the budget
+// granted again when an exhausted PRD is unblocked or escalated
+const DefaultMaxIterations = 3
+
 // NewPRD creates a new PRD with defaults
 func NewPRD(title string) *PRD {
 	now := time.Now()
@@ -97,6 +195,118 @@ func NewPRD(title string) *PRD {
 		CreatedAt:        now,
 		UpdatedAt:        now,
 		CurrentIteration: 0,
-		MaxIterations:    3,
+		MaxIterations:    DefaultMaxIterations,
+	}
+}
+
+// FillDefaults sets the bookkeeping fields a hand- or agent-written PRD may
+// omit: ID, version, status, timestamps and iteration budget. Returns
+// whether anything was set.
+func (p *PRD) FillDefaults() bool {
+	now := time.Now()
+	filled := false
+	if p.ID == "" && p.Title != "" {
+		p.ID = GenerateID(p.Title)
+		filled = true
+	}
+	if p.Version == "" {
+		p.Version = "1.0"
+		filled = true
+	}
+	if p.Status == "" {
+		p.Status = types.StatusPending
+		filled = true
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = now
+		filled = true
+	}
+	if p.UpdatedAt.IsZero() {
+		p.UpdatedAt = now
+		filled = true
+	}
+	if p.MaxIterations == 0 {
+		p.MaxIterations = DefaultMaxIterations
+		filled = true
+	}
+	return filled
+}
+
+// SetStatus changes the PRD status and maintains the lifecycle timestamps
+func (p *PRD) SetStatus(status types.Status) {
+	now := time.Now()
+	p.Status = status
+	p.UpdatedAt = now
+
+	switch status {
+	case types.StatusInProgress:
+		if p.StartedAt == nil {
+			p.StartedAt = &now
+		}
+	case types.StatusComplete:
+		p.CompletedAt = &now
+	default:
+		p.CompletedAt = nil
+	}
+}
+
+// StartAttempt advances the iteration counter and records a new attempt
+func (p *PRD) StartAttempt(baseCommit string) *Attempt {
+	p.CurrentIteration++
+	p.Attempts = append(p.Attempts, Attempt{
+		Iteration:  p.CurrentIteration,
+		StartedAt:  time.Now(),
+		BaseCommit: baseCommit,
+	})
+	p.SetStatus(types.StatusInProgress)
+	return &p.Attempts[len(p.Attempts)-1]
+}
+
+// Reopen returns a PRD to pending so it runs again, dropping any unblock
+// conditions and granting a fresh iteration budget if it was used up
+func (p *PRD) Reopen() {
+	p.UnblockWhen = nil
+	if p.IterationsExhausted() {
+		p.ExtendIterations(DefaultMaxIterations)
+	}
+	p.SetStatus(types.StatusPending)
+}
+
+// IterationsExhausted reports whether the PRD has used its whole iteration
+// budget. PRDs without a budget get the default one.
+func (p *PRD) IterationsExhausted() bool {
+	limit := p.MaxIterations
+	if limit <= 0 {
+		limit = DefaultMaxIterations
+	}
+	return p.CurrentIteration >= limit
+}
+
+// ExtendIterations grants n more iterations beyond those already used
+func (p *PRD) ExtendIterations(n int) {
+	p.MaxIterations = p.CurrentIteration + n
+	p.UpdatedAt = time.Now()
+}
+
+// ConsecutiveBailouts counts the trailing attempts that ended in a bailout
+func (p *PRD) ConsecutiveBailouts() int {
+	n := 0
+	for i := len(p.Attempts) - 1; i >= 0 && p.Attempts[i].Outcome == types.OutcomePartial; i-- {
+		n++
+	}
+	return n
+}
+
+// Unfinished reports whether the attempt never ended, which happens when
+// Ralph is killed mid-iteration
+func (a *Attempt) Unfinished() bool {
+	return a.EndedAt.IsZero()
+}
+
+// LastAttempt returns the most recent attempt, or nil if none exist
+func (p *PRD) LastAttempt() *Attempt {
+	if len(p.Attempts) == 0 {
+		return nil
 	}
+	return &p.Attempts[len(p.Attempts)-1]
 }
@@ -0,0 +1,102 @@
+package prd
+
+import (
+	"testing"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestNewUnblockCondition(t *testing.T) {
+	tests := []struct {
+		kind    string
+		value   string
+		want    UnblockCondition
+		wantErr bool
+	}{
+		{UnblockCommand, "test -f .env", UnblockCondition{Command: "test -f .env"}, false},
+		{UnblockFileExists, "config/keys.json", UnblockCondition{FileExists: "config/keys.json"}, false},
+		{UnblockPRDComplete, "auth-a1b2", UnblockCondition{PRDComplete: "auth-a1b2"}, false},
+		{"url_reachable", "http://localhost", UnblockCondition{}, true},
+		{UnblockCommand, "", UnblockCondition{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind+":"+tt.value, func(t *testing.T) {
+			got, err := NewUnblockCondition(tt.kind, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if !tt.wantErr && got.fieldsSet() != 1 {
+				t.Errorf("fieldsSet() = %d, want 1", got.fieldsSet())
+			}
+		})
+	}
+}
+
+func TestIterationsExhausted(t *testing.T) {
+	tests := []struct {
+		current, max int
+		want         bool
+	}{
+		{0, 3, false},
+		{2, 3, false},
+		{3, 3, true},
+		{4, 3, true},
+		{3, 0, true}, // unset budget falls back to the default
+		{2, 0, false},
+	}
+
+	for _, tt := range tests {
+		p := &PRD{CurrentIteration: tt.current, MaxIterations: tt.max}
+		if got := p.IterationsExhausted(); got != tt.want {
+			t.Errorf("IterationsExhausted() with %d/%d = %v, want %v", tt.current, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestConsecutiveBailouts(t *testing.T) {
+	outcomes := func(list ...types.Outcome) *PRD {
+		p := &PRD{}
+		for _, o := range list {
+			p.Attempts = append(p.Attempts, Attempt{Outcome: o})
+		}
+		return p
+	}
+
+	tests := []struct {
+		name string
+		prd  *PRD
+		want int
+	}{
+		{"no attempts", outcomes(), 0},
+		{"trailing bailouts", outcomes(types.OutcomeFailed, types.OutcomePartial, types.OutcomePartial), 2},
+		{"interrupted", outcomes(types.OutcomePartial, types.OutcomeNoProgress), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.prd.ConsecutiveBailouts(); got != tt.want {
+				t.Errorf("ConsecutiveBailouts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillDefaults(t *testing.T) {
+	p := &PRD{Title: "Rate limit API", MaxIterations: 5}
+	if !p.FillDefaults() {
+		t.Fatal("FillDefaults() = false on a bare PRD")
+	}
+	if p.ID == "" || p.Version == "" || p.Status != types.StatusPending || p.CreatedAt.IsZero() {
+		t.Errorf("defaults not filled: %+v", p)
+	}
+	if p.MaxIterations != 5 {
+		t.Errorf("MaxIterations = %d, want the explicit 5 kept", p.MaxIterations)
+	}
+	if p.FillDefaults() {
+		t.Error("FillDefaults() = true on a complete PRD")
+	}
+}
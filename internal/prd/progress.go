@@ -2,63 +2,117 @@ package prd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/utils"
 )
 
-// Progress tracks execution progress across PRDs
+// ProgressVersion is the progress.json schema version written by this build
+const ProgressVersion = "2.0"
+
+// ErrEntryExists is returned when appending an entry whose ID is already recorded
+var ErrEntryExists = errors.New("progress entry already exists")
+
+// Progress tracks execution history across PRDs (.ralph/progress.json).
+// Entries are append-only: each one records a finished iteration.
 type Progress struct {
-	SchemaVersion    string            `json:"schema_version"`
-	CodebasePatterns []CodebasePattern `json:"codebase_patterns"`
-	Observations     []ProgressObs     `json:"observations"`
-	Learnings        []Learning        `json:"learnings"`
-	PRDCompletions   []PRDCompletion   `json:"prd_completions"`
+	SchemaVersion string          `json:"schema_version"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ProjectName   string          `json:"project_name,omitempty"`
+	Entries       []ProgressEntry `json:"entries"`
+	Learnings     []Learning      `json:"learnings"`
+	Patterns      []Pattern       `json:"patterns"`
+
+	ArchivedEntries int        `json:"archived_entries,omitempty"`  // entries moved to progress-archive/ by compaction
+	CodebaseState   string     `json:"codebase_state,omitempty"`    // summarized state of the codebase, inlined into build prompts
+	CodebaseStateAt *time.Time `json:"codebase_state_at,omitempty"` // when CodebaseState was last summarized
 }
 
-// CodebasePattern represents a discovered pattern in the codebase
-type CodebasePattern struct {
-	Pattern      string    `json:"pattern"`
-	DiscoveredAt time.Time `json:"discovered_at,omitempty"`
-	DiscoveredIn string    `json:"discovered_in,omitempty"`
+// ProgressEntry records a single iteration of a PRD
+type ProgressEntry struct {
+	ID                 string               `json:"id"` // prd_id-iteration
+	Timestamp          time.Time            `json:"timestamp"`
+	PRDID              string               `json:"prd_id"`
+	Iteration          int                  `json:"iteration"`
+	Status             types.ProgressStatus `json:"status"`
+	VerificationPassed *bool                `json:"verification_passed,omitempty"` // nil when verification didn't run
+	DurationSeconds    int                  `json:"duration_seconds,omitempty"`
+	Summary            string               `json:"summary,omitempty"`
+	Observations       []ProgressObs        `json:"observations"`
+	FilesModified      []string             `json:"files_modified,omitempty"`
+	GitCommits         []string             `json:"git_commits,omitempty"`
+	Context            *IterationContext    `json:"context,omitempty"`
 }
 
-// ProgressObs represents an observation made during execution
+// ProgressObs is a finding captured during an iteration
 type ProgressObs struct {
-	Timestamp   time.Time `json:"timestamp,omitempty"`
-	PRDID       string    `json:"prd_id,omitempty"`
-	Observation string    `json:"observation"`
-	Category    string    `json:"category,omitempty"`
+	Type              types.ObservationType `json:"type"`
+	Title             string                `json:"title"`
+	Description       string                `json:"description,omitempty"`
+	File              string                `json:"file,omitempty"`
+	Category          types.ObsCategory     `json:"category,omitempty"`
+	Severity          types.ObsSeverity     `json:"severity,omitempty"`
+	ActionTaken       types.ObsAction       `json:"action_taken,omitempty"`
+	RelatedLearningID string                `json:"related_learning_id,omitempty"`
+}
+
+// IterationContext records why an iteration ran the way it did
+type IterationContext struct {
+	RetryCount            int                  `json:"retry_count,omitempty"`
+	PreviousFailureReason string               `json:"previous_failure_reason,omitempty"`
+	RecoveryAction        types.RecoveryAction `json:"recovery_action,omitempty"`
+	RecoveryGuidance      string               `json:"recovery_guidance,omitempty"`
+	DependenciesCompleted []string             `json:"dependencies_completed,omitempty"`
 }
 
-// Learning represents something learned during execution
+// Learning is something learned during execution that applies across iterations
 type Learning struct {
-	Timestamp time.Time `json:"timestamp,omitempty"`
-	PRDID     string    `json:"prd_id,omitempty"`
-	Learning  string    `json:"learning"`
-	AppliesTo []string  `json:"applies_to,omitempty"`
+	ID              string             `json:"id"` // learning-NNNN
+	Type            types.LearningType `json:"type,omitempty"`
+	Content         string             `json:"content"`
+	Context         string             `json:"context,omitempty"`    // when/where it applies
+	AppliesTo       []string           `json:"applies_to,omitempty"` // files, directories, globs or tags it is relevant to
+	SourcePRDID     string             `json:"source_prd_id,omitempty"`
+	SourceEntryID   string             `json:"source_entry_id,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	TimesReferenced int                `json:"times_referenced,omitempty"`
+	StillValid      bool               `json:"still_valid"`
 }
 
-// PRDCompletion records a completed PRD
-type PRDCompletion struct {
-	PRDID              string    `json:"prd_id"`
-	CompletedAt        time.Time `json:"completed_at"`
-	Summary            string    `json:"summary,omitempty"`
-	FilesChanged       []string  `json:"files_changed,omitempty"`
-	VerificationPassed bool      `json:"verification_passed"`
+// Pattern is a codebase pattern discovered during execution
+type Pattern struct {
+	ID           string                  `json:"id"` // pattern-NNNN
+	Name         string                  `json:"name"`
+	Type         types.PatternType       `json:"type,omitempty"`
+	Description  string                  `json:"description,omitempty"`
+	Examples     []string                `json:"examples,omitempty"`
+	DiscoveredAt time.Time               `json:"discovered_at"`
+	SourcePRDID  string                  `json:"source_prd_id,omitempty"`
+	Confidence   types.PatternConfidence `json:"confidence,omitempty"`
 }
 
 // NewProgress creates a new Progress with defaults
 func NewProgress() *Progress {
 	return &Progress{
-		SchemaVersion:    "1.0",
-		CodebasePatterns: []CodebasePattern{},
-		Observations:     []ProgressObs{},
-		Learnings:        []Learning{},
-		PRDCompletions:   []PRDCompletion{},
+		SchemaVersion: ProgressVersion,
+		CreatedAt:     time.Now(),
+		Entries:       []ProgressEntry{},
+		Learnings:     []Learning{},
+		Patterns:      []Pattern{},
 	}
 }
 
+// EntryID returns the ID of the entry for a PRD iteration
+func EntryID(prdID string, iteration int) string {
+	return fmt.Sprintf("%s-%d", prdID, iteration)
+}
+
 // Validate ensures the progress is valid
 func (p *Progress) Validate() error {
 	if p.SchemaVersion == "" {
@@ -67,19 +121,47 @@ func (p *Progress) Validate() error {
 	return nil
 }
 
-// LoadProgress reads and parses a progress JSON file
+// LoadProgress reads a progress JSON file, upgrading older schema versions
+// to ProgressVersion. The upgrade is written back on the next Save.
 func LoadProgress(path string) (*Progress, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	var progress Progress
-	if err := json.Unmarshal(data, &progress); err != nil {
+	progress, err := parseProgress(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 	}
 
-	return &progress, nil
+	return progress, nil
+}
+
+// parseProgress decodes progress.json contents of any supported version
+func parseProgress(data []byte) (*Progress, error) {
+	var header struct {
+		SchemaVersion string `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	switch header.SchemaVersion {
+	case "1.0":
+		var legacy progressV1
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return nil, err
+		}
+		return legacy.upgrade(), nil
+	case ProgressVersion:
+		var progress Progress
+		if err := json.Unmarshal(data, &progress); err != nil {
+			return nil, err
+		}
+		return &progress, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema_version %q (this version of ralph reads 1.0 and %s)", header.SchemaVersion, ProgressVersion)
+	}
 }
 
 // Save writes the progress to disk
@@ -89,49 +171,569 @@ func (p *Progress) Save(path string) error {
 		return fmt.Errorf("failed to marshal progress: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write %s: %w", path, err)
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-// AddObservation adds an observation to the progress
-func (p *Progress) AddObservation(prdID, observation, category string) {
-	p.Observations = append(p.Observations, ProgressObs{
-		Timestamp:   time.Now(),
-		PRDID:       prdID,
-		Observation: observation,
-		Category:    category,
-	})
+// LoadOrNewProgress reads a progress file, returning a fresh Progress if it does not exist yet
+func LoadOrNewProgress(path string) (*Progress, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return NewProgress(), nil
+	}
+	return LoadProgress(path)
 }
 
-// AddLearning adds a learning to the progress
-func (p *Progress) AddLearning(prdID, learning string, appliesTo []string) {
-	p.Learnings = append(p.Learnings, Learning{
-		Timestamp: time.Now(),
-		PRDID:     prdID,
-		Learning:  learning,
-		AppliesTo: appliesTo,
-	})
+// FindEntry returns the entry with the given ID, or nil
+func (p *Progress) FindEntry(id string) *ProgressEntry {
+	for i := range p.Entries {
+		if p.Entries[i].ID == id {
+			return &p.Entries[i]
+		}
+	}
+	return nil
 }
 
-// AddPattern adds a codebase pattern to the progress
-func (p *Progress) AddPattern(pattern, discoveredIn string) {
-	p.CodebasePatterns = append(p.CodebasePatterns, CodebasePattern{
-		Pattern:      pattern,
-		DiscoveredAt: time.Now(),
-		DiscoveredIn: discoveredIn,
-	})
+// AppendEntry records a finished iteration. The ID is derived from the PRD
+// and iteration; an entry that is already recorded is never rewritten.
+func (p *Progress) AppendEntry(entry ProgressEntry) error {
+	entry.ID = EntryID(entry.PRDID, entry.Iteration)
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.Observations == nil {
+		entry.Observations = []ProgressObs{}
+	}
+	if p.FindEntry(entry.ID) != nil {
+		return fmt.Errorf("%w: %s", ErrEntryExists, entry.ID)
+	}
+	if errs := entry.validate(); errs.HasErrors() {
+		return fmt.Errorf("invalid progress entry %s: %w", entry.ID, errs)
+	}
+
+	p.Entries = append(p.Entries, entry)
+	return nil
 }
 
-// RecordCompletion records a PRD completion
-func (p *Progress) RecordCompletion(prdID, summary string, filesChanged []string, verificationPassed bool) {
-	p.PRDCompletions = append(p.PRDCompletions, PRDCompletion{
-		PRDID:              prdID,
-		CompletedAt:        time.Now(),
-		Summary:            summary,
-		FilesChanged:       filesChanged,
-		VerificationPassed: verificationPassed,
+// nextSeq returns one more than the highest numeric suffix among n IDs
+func nextSeq(n int, id func(int) string, prefix string) int {
+	highest := 0
+	for i := 0; i < n; i++ {
+		var seq int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(id(i), prefix), "%d", &seq); err == nil && seq > highest {
+			highest = seq
+		}
+	}
+	return highest + 1
+}
+
+// ValidateWithDetails performs rich validation for self-healing
+func (p *Progress) ValidateWithDetails() *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+
+	if p.SchemaVersion == "" {
+		errs.Add("schema_version", "non-empty string", "", fmt.Sprintf("Provide schema version %q", ProgressVersion))
+	}
+	if p.CreatedAt.IsZero() {
+		errs.Add("created_at", "ISO 8601 timestamp", nil, "Provide created_at timestamp")
+	}
+
+	seen := make(map[string]bool)
+	for i, e := range p.Entries {
+		field := fmt.Sprintf("entries[%d]", i)
+		if e.ID != "" && seen[e.ID] {
+			errs.Add(field+".id", "unique entry ID", e.ID, "Entry IDs are prd_id-iteration and must be unique")
+		}
+		seen[e.ID] = true
+		errs.Merge(field, e.validate())
+	}
+	for i, l := range p.Learnings {
+		errs.Merge(fmt.Sprintf("learnings[%d]", i), l.validate())
+	}
+	for i, pat := range p.Patterns {
+		errs.Merge(fmt.Sprintf("patterns[%d]", i), pat.validate())
+	}
+
+	return errs
+}
+
+func (l *Learning) validate() *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+	if l.ID == "" {
+		errs.Add("id", "non-empty string", "", "Provide an ID like \"learning-0001\"")
+	}
+	if l.Content == "" {
+		errs.Add("content", "non-empty string", "", "Describe the learning or remove the entry")
+	}
+	if !l.Type.IsValid() {
+		errs.Add("type", fmt.Sprintf("one of: %v", types.AllLearningTypes()), l.Type, "Use a valid learning type or omit it")
+	}
+	if l.CreatedAt.IsZero() {
+		errs.Add("created_at", "ISO 8601 timestamp", nil, "Provide created_at timestamp")
+	}
+	return errs
+}
+
+func (pat *Pattern) validate() *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+	if pat.ID == "" {
+		errs.Add("id", "non-empty string", "", "Provide an ID like \"pattern-0001\"")
+	}
+	if pat.Name == "" {
+		errs.Add("name", "non-empty string", "", "Name the pattern or remove the entry")
+	}
+	if !pat.Type.IsValid() {
+		errs.Add("type", fmt.Sprintf("one of: %v", types.AllPatternTypes()), pat.Type, "Use a valid pattern type or omit it")
+	}
+	if !pat.Confidence.IsValid() {
+		errs.Add("confidence", fmt.Sprintf("one of: %v", types.AllPatternConfidences()), pat.Confidence, "Use a valid confidence or omit it")
+	}
+	if pat.DiscoveredAt.IsZero() {
+		errs.Add("discovered_at", "ISO 8601 timestamp", nil, "Provide discovered_at timestamp")
+	}
+	return errs
+}
+
+func (e *ProgressEntry) validate() *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+
+	if e.PRDID == "" {
+		errs.Add("prd_id", "non-empty string", "", "Provide the ID of the PRD this iteration worked on")
+	}
+	if e.Iteration < 1 {
+		errs.Add("iteration", "integer >= 1", e.Iteration, "Set the PRD iteration this entry records")
+	}
+	if want := EntryID(e.PRDID, e.Iteration); e.ID != want {
+		errs.Add("id", "prd_id-iteration", e.ID, fmt.Sprintf("Set id to %q", want))
+	}
+	if e.Timestamp.IsZero() {
+		errs.Add("timestamp", "ISO 8601 timestamp", nil, "Provide timestamp")
+	}
+	if !e.Status.IsValid() {
+		errs.Add("status", fmt.Sprintf("one of: %v", types.AllProgressStatuses()), e.Status, "Use a valid progress status")
+	}
+	if e.DurationSeconds < 0 {
+		errs.Add("duration_seconds", "integer >= 0", e.DurationSeconds, "Duration cannot be negative")
+	}
+	for i, obs := range e.Observations {
+		field := fmt.Sprintf("observations[%d]", i)
+		if !obs.Type.IsValid() {
+			errs.Add(field+".type", fmt.Sprintf("one of: %v", types.AllObservationTypes()), obs.Type, "Use a valid observation type")
+		}
+		if obs.Title == "" {
+			errs.Add(field+".title", "non-empty string", "", "Give the observation a short title")
+		}
+		if !obs.Category.IsValid() {
+			errs.Add(field+".category", fmt.Sprintf("one of: %v", types.AllObsCategories()), obs.Category, "Use a valid category or omit it")
+		}
+		if !obs.Severity.IsValid() {
+			errs.Add(field+".severity", fmt.Sprintf("one of: %v", types.AllObsSeverities()), obs.Severity, "Use a valid severity or omit it")
+		}
+		if !obs.ActionTaken.IsValid() {
+			errs.Add(field+".action_taken", fmt.Sprintf("one of: %v", types.AllObsActions()), obs.ActionTaken, "Use a valid action or omit it")
+		}
+	}
+	if e.Context != nil && !e.Context.RecoveryAction.IsValid() {
+		errs.Add("context.recovery_action", fmt.Sprintf("one of: %v", types.AllRecoveryActions()), e.Context.RecoveryAction, "Use a valid recovery action or omit it")
+	}
+
+	return errs
+}
+
+// checkAppendOnly reports entries from before that were removed, reordered or rewritten
+func (p *Progress) checkAppendOnly(before *Progress) *types.ValidationErrors {
+	errs := &types.ValidationErrors{}
+	for i, old := range before.Entries {
+		field := fmt.Sprintf("entries[%d]", i)
+		if i >= len(p.Entries) {
+			errs.Add(field, "existing entry "+old.ID, nil, "Entries are append-only; restore the removed entry "+old.ID)
+			continue
+		}
+		if !sameEntry(old, p.Entries[i]) {
+			errs.Add(field, "unchanged entry "+old.ID, p.Entries[i].ID, "Entries are append-only; undo changes to "+old.ID+" and add new information as a new entry")
+		}
+	}
+	return errs
+}
+
+func sameEntry(a, b ProgressEntry) bool {
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(left) == string(right)
+}
+
+// ValidateProgressFile parses and validates a progress file on disk. A
+// missing file is valid. When before holds the file's previous contents,
+// entries recorded in it must be unchanged.
+func ValidateProgressFile(path string, before []byte) *types.ValidationErrors {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &types.ValidationErrors{}
+	}
+	if err != nil {
+		errs := &types.ValidationErrors{}
+		errs.Add("(file)", "readable file", path, err.Error())
+		return errs
+	}
+
+	progress, err := parseProgress(data)
+	if err != nil {
+		return syntaxError(err)
+	}
+
+	errs := progress.ValidateWithDetails()
+	if before != nil {
+		if previous, err := parseProgress(before); err == nil {
+			errs.Merge("", progress.checkAppendOnly(previous))
+		}
+	}
+	return errs
+}
+
+// RestoreProgress undoes an iteration of prdID in the progress file at
+// path, given before, the file's contents when the iteration started. The
+// snapshot is kept as it was, plus the valid entries, learnings and
+// patterns other workers added since, so their history isn't lost. Only a
+// file that isn't a JSON object gets the whole snapshot back.
+func RestoreProgress(path string, before []byte, prdID string) error {
+	merged := NewProgress()
+	if before != nil {
+		snapshot, err := parseProgress(before)
+		if err != nil {
+			return fmt.Errorf("failed to parse progress snapshot: %w", err)
+		}
+		merged = snapshot
+	}
+
+	data, err := os.ReadFile(path)
+	var current *Progress
+	if err == nil {
+		current, err = salvageProgress(data)
+	}
+	if err != nil {
+		return restoreFile(path, before)
+	}
+
+	for _, e := range current.Entries {
+		if e.PRDID != prdID && merged.FindEntry(e.ID) == nil && !e.validate().HasErrors() {
+			merged.Entries = append(merged.Entries, e)
+		}
+	}
+	learnings := make(map[string]bool, len(merged.Learnings))
+	for _, l := range merged.Learnings {
+		learnings[l.ID] = true
+	}
+	for _, l := range current.Learnings {
+		if l.SourcePRDID != prdID && !learnings[l.ID] && !l.validate().HasErrors() {
+			merged.Learnings = append(merged.Learnings, l)
+			learnings[l.ID] = true
+		}
+	}
+	patterns := make(map[string]bool, len(merged.Patterns))
+	for _, pat := range merged.Patterns {
+		patterns[pat.ID] = true
+	}
+	for _, pat := range current.Patterns {
+		if pat.SourcePRDID != prdID && !patterns[pat.ID] && !pat.validate().HasErrors() {
+			merged.Patterns = append(merged.Patterns, pat)
+			patterns[pat.ID] = true
+		}
+	}
+	if at := current.CodebaseStateAt; at != nil && (merged.CodebaseStateAt == nil || at.After(*merged.CodebaseStateAt)) {
+		merged.CodebaseState, merged.CodebaseStateAt = current.CodebaseState, at
+	}
+	return merged.Save(path)
+}
+
+// salvageProgress decodes as much of a progress file as it can. Entries,
+// learnings and patterns that don't parse are left out; an error means the
+// file isn't a JSON object at all.
+func salvageProgress(data []byte) (*Progress, error) {
+	if progress, err := parseProgress(data); err == nil {
+		return progress, nil
+	}
+
+	var raw struct {
+		Entries   []json.RawMessage `json:"entries"`
+		Learnings []json.RawMessage `json:"learnings"`
+		Patterns  []json.RawMessage `json:"patterns"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return &Progress{
+		Entries:   decodeEach[ProgressEntry](raw.Entries),
+		Learnings: decodeEach[Learning](raw.Learnings),
+		Patterns:  decodeEach[Pattern](raw.Patterns),
+	}, nil
+}
+
+// decodeEach decodes the items that parse as T, skipping the rest
+func decodeEach[T any](raws []json.RawMessage) []T {
+	var items []T
+	for _, raw := range raws {
+		var item T
+		if json.Unmarshal(raw, &item) == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// progressV1 is the flat 1.0 layout: observations, learnings and completions
+// were separate lists rather than per-iteration entries
+type progressV1 struct {
+	CodebasePatterns []struct {
+		Pattern      string    `json:"pattern"`
+		DiscoveredAt time.Time `json:"discovered_at"`
+		DiscoveredIn string    `json:"discovered_in"`
+	} `json:"codebase_patterns"`
+	Observations []struct {
+		Timestamp   time.Time `json:"timestamp"`
+		PRDID       string    `json:"prd_id"`
+		Observation string    `json:"observation"`
+		Category    string    `json:"category"`
+	} `json:"observations"`
+	Learnings []struct {
+		Timestamp time.Time `json:"timestamp"`
+		PRDID     string    `json:"prd_id"`
+		Learning  string    `json:"learning"`
+		AppliesTo []string  `json:"applies_to"`
+	} `json:"learnings"`
+	PRDCompletions []struct {
+		PRDID              string    `json:"prd_id"`
+		CompletedAt        time.Time `json:"completed_at"`
+		Summary            string    `json:"summary"`
+		FilesChanged       []string  `json:"files_changed"`
+		VerificationPassed bool      `json:"verification_passed"`
+	} `json:"prd_completions"`
+}
+
+// legacyPRDID stands in for 1.0 observations that were not tied to a PRD
+const legacyPRDID = "legacy"
+
+// upgrade converts a 1.0 file. Each completion becomes a completed entry;
+// observations attach to their PRD's first entry, creating a partial entry
+// for PRDs that never completed.
+func (v *progressV1) upgrade() *Progress {
+	now := time.Now()
+	p := NewProgress()
+
+	iterations := make(map[string]int)
+	firstEntry := make(map[string]int)
+	newEntry := func(prdID string, status types.ProgressStatus, at time.Time) *ProgressEntry {
+		if prdID == "" {
+			prdID = legacyPRDID
+		}
+		if at.IsZero() {
+			at = now
+		}
+		iterations[prdID]++
+		p.Entries = append(p.Entries, ProgressEntry{
+			ID:           EntryID(prdID, iterations[prdID]),
+			Timestamp:    at,
+			PRDID:        prdID,
+			Iteration:    iterations[prdID],
+			Status:       status,
+			Observations: []ProgressObs{},
+		})
+		if _, ok := firstEntry[prdID]; !ok {
+			firstEntry[prdID] = len(p.Entries) - 1
+		}
+		return &p.Entries[len(p.Entries)-1]
+	}
+
+	for _, c := range v.PRDCompletions {
+		e := newEntry(c.PRDID, types.ProgressCompleted, c.CompletedAt)
+		e.Summary = c.Summary
+		e.FilesModified = c.FilesChanged
+		passed := c.VerificationPassed
+		e.VerificationPassed = &passed
+	}
+	for _, o := range v.Observations {
+		prdID := o.PRDID
+		if prdID == "" {
+			prdID = legacyPRDID
+		}
+		if _, ok := firstEntry[prdID]; !ok {
+			newEntry(prdID, types.ProgressPartial, o.Timestamp)
+		}
+		e := &p.Entries[firstEntry[prdID]]
+		obs := ProgressObs{Type: types.ObsFinding, Title: title(o.Observation)}
+		if obs.Title != o.Observation {
+			obs.Description = o.Observation
+		}
+		if category := types.ObsCategory(o.Category); category.IsValid() {
+			obs.Category = category
+		}
+		e.Observations = append(e.Observations, obs)
+	}
+	sort.SliceStable(p.Entries, func(i, j int) bool {
+		return p.Entries[i].Timestamp.Before(p.Entries[j].Timestamp)
 	})
+
+	for _, l := range v.Learnings {
+		created := l.Timestamp
+		if created.IsZero() {
+			created = now
+		}
+		p.AddLearning(Learning{
+			Content:     l.Learning,
+			AppliesTo:   l.AppliesTo,
+			SourcePRDID: l.PRDID,
+			CreatedAt:   created,
+		})
+	}
+	for _, pat := range v.CodebasePatterns {
+		discovered := pat.DiscoveredAt
+		if discovered.IsZero() {
+			discovered = now
+		}
+		added := Pattern{
+			Name:         title(pat.Pattern),
+			SourcePRDID:  pat.DiscoveredIn,
+			DiscoveredAt: discovered,
+		}
+		if added.Name != pat.Pattern {
+			added.Description = pat.Pattern
+		}
+		p.AddPattern(added)
+	}
+
+	if len(p.Entries) > 0 {
+		p.CreatedAt = p.Entries[0].Timestamp
+	}
+	return p
+}
+
+// title returns the first line of s, shortened to a title-sized length
+func title(s string) string {
+	s = strings.TrimSpace(s)
+	if line, _, found := strings.Cut(s, "\n"); found {
+		s = strings.TrimSpace(line)
+	}
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
+
+// EntryFromAttempt builds the progress entry for a finished attempt. Git
+// details (files modified, commits) are left for the caller to fill in.
+func EntryFromAttempt(p *PRD, a *Attempt) ProgressEntry {
+	entry := ProgressEntry{
+		PRDID:        p.ID,
+		Iteration:    a.Iteration,
+		Status:       attemptStatus(a),
+		Observations: []ProgressObs{},
+		Context: &IterationContext{
+			RetryCount:            a.Iteration - 1,
+			DependenciesCompleted: p.DependsOn,
+		},
+	}
+	if !a.EndedAt.IsZero() {
+		entry.Timestamp = a.EndedAt
+		entry.DurationSeconds = int(a.EndedAt.Sub(a.StartedAt).Seconds())
+	}
+
+	if a.Blocker != "" {
+		entry.Summary = "Blocked: " + a.Blocker
+		entry.Observations = append(entry.Observations, ProgressObs{
+			Type:        types.ObsBlocker,
+			Title:       title(a.Blocker),
+			Description: a.Blocker,
+			ActionTaken: types.ObsActionEscalated,
+		})
+	}
+	for _, o := range a.Observations {
+		entry.Observations = append(entry.Observations, ProgressObs{Type: types.ObsFinding, Title: title(o), Description: o})
+	}
+	if a.Review != nil {
+		entry.Summary = a.Review.Summary
+		passed := a.Review.VerificationPassed
+		entry.VerificationPassed = &passed
+		for _, name := range a.Review.NewFailures {
+			entry.Observations = append(entry.Observations, ProgressObs{
+				Type:     types.ObsFinding,
+				Title:    title("New failure: " + name),
+				Category: types.ObsCatTestFailure,
+			})
+		}
+		for _, c := range a.Review.Criteria {
+			if !c.Met {
+				entry.Observations = append(entry.Observations, ProgressObs{
+					Type:        types.ObsFinding,
+					Title:       title("Criterion unmet: " + c.Criterion),
+					Description: c.Reason,
+				})
+			}
+		}
+	}
+	if entry.Summary == "" && len(a.Observations) > 0 {
+		entry.Summary = a.Observations[len(a.Observations)-1]
+	}
+
+	if prev := previousAttempt(p, a); prev != nil {
+		entry.Context.PreviousFailureReason = failureReason(prev)
+		entry.Context.RecoveryAction = recoveryAction(prev.Recovery)
+	}
+	return entry
+}
+
+// attemptStatus maps an attempt's outcome and review to a progress status
+func attemptStatus(a *Attempt) types.ProgressStatus {
+	if a.Review != nil {
+		if a.Review.Passed {
+			return types.ProgressCompleted
+		}
+		return types.ProgressFailed
+	}
+	switch a.Outcome {
+	case types.OutcomeComplete:
+		return types.ProgressCompleted
+	case types.OutcomeBlocked:
+		return types.ProgressBlocked
+	case types.OutcomeFailed:
+		return types.ProgressFailed
+	default:
+		return types.ProgressPartial
+	}
+}
+
+func previousAttempt(p *PRD, a *Attempt) *Attempt {
+	for i := len(p.Attempts) - 1; i >= 0; i-- {
+		if p.Attempts[i].Iteration < a.Iteration {
+			return &p.Attempts[i]
+		}
+	}
+	return nil
+}
+
+// recoveryAction maps how an interrupted attempt was recovered to the
+// action recorded on the iteration that follows it
+func recoveryAction(a types.InterruptAction) types.RecoveryAction {
+	switch a {
+	case types.InterruptResume:
+		return types.RecoveryRetry
+	case types.InterruptReset:
+		return types.RecoveryFixState
+	case types.InterruptBlock:
+		return types.RecoveryManual
+	}
+	return ""
+}
+
+// failureReason summarizes why an attempt did not complete, or "" if it did
+func failureReason(a *Attempt) string {
+	switch {
+	case a.Review != nil && !a.Review.Passed:
+		return a.Review.Summary
+	case a.Blocker != "":
+		return a.Blocker
+	case a.Outcome != types.OutcomeComplete && len(a.Observations) > 0:
+		return a.Observations[len(a.Observations)-1]
+	}
+	return ""
 }
@@ -0,0 +1,265 @@
+package prd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+const progressV1JSON = `{
+  "schema_version": "1.0",
+  "codebase_patterns": [{"pattern": "Handlers live in internal/handlers", "discovered_in": "auth"}],
+  "observations": [
+    {"timestamp": "2025-01-15T10:00:00Z", "prd_id": "auth", "observation": "Middleware uses RS256", "category": "architecture"},
+    {"timestamp": "2025-01-15T11:00:00Z", "prd_id": "billing", "observation": "Stripe key missing", "category": "not-a-category"}
+  ],
+  "learnings": [{"prd_id": "auth", "learning": "Run make gen first", "applies_to": ["build"]}],
+  "prd_completions": [{"prd_id": "auth", "completed_at": "2025-01-15T10:30:00Z", "summary": "Login done", "files_changed": ["auth.go"], "verification_passed": true}]
+}`
+
+func TestParseProgressUpgradesV1(t *testing.T) {
+	p, err := parseProgress([]byte(progressV1JSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.SchemaVersion != ProgressVersion {
+		t.Errorf("SchemaVersion = %q, want %q", p.SchemaVersion, ProgressVersion)
+	}
+	if errs := p.ValidateWithDetails(); errs.HasErrors() {
+		t.Fatalf("upgraded progress is invalid: %s", errs.ToPrompt())
+	}
+
+	tests := []struct {
+		id           string
+		status       types.ProgressStatus
+		observations int
+	}{
+		{"auth-1", types.ProgressCompleted, 1},
+		{"billing-1", types.ProgressPartial, 1},
+	}
+	for _, tt := range tests {
+		e := p.FindEntry(tt.id)
+		if e == nil {
+			t.Errorf("entry %s missing", tt.id)
+			continue
+		}
+		if e.Status != tt.status || len(e.Observations) != tt.observations {
+			t.Errorf("entry %s = %s with %d observations, want %s with %d", tt.id, e.Status, len(e.Observations), tt.status, tt.observations)
+		}
+	}
+	if got := p.FindEntry("auth-1").VerificationPassed; got == nil || !*got {
+		t.Errorf("auth-1 verification_passed = %v, want true", got)
+	}
+	if got := p.FindEntry("billing-1").VerificationPassed; got != nil {
+		t.Errorf("billing-1 verification_passed = %v, want unset", *got)
+	}
+	if got := p.FindEntry("billing-1").Observations[0].Category; got != "" {
+		t.Errorf("invalid category carried over: %q", got)
+	}
+	if len(p.Learnings) != 1 || p.Learnings[0].ID != "learning-0001" || !p.Learnings[0].StillValid {
+		t.Errorf("learnings = %+v", p.Learnings)
+	}
+	if len(p.Patterns) != 1 || p.Patterns[0].ID != "pattern-0001" {
+		t.Errorf("patterns = %+v", p.Patterns)
+	}
+}
+
+func TestEntryFromAttemptVerification(t *testing.T) {
+	p := validChild("Login")
+	tests := []struct {
+		name   string
+		review *Review
+		want   *bool
+	}{
+		{"no review", nil, nil},
+		{"verification passed", &Review{Passed: true, VerificationPassed: true}, ptr(true)},
+		{"verification failed", &Review{VerificationPassed: false}, ptr(false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := EntryFromAttempt(p, &Attempt{Iteration: 1, Outcome: types.OutcomeComplete, Review: tt.review})
+			got := entry.VerificationPassed
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("VerificationPassed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryFromAttemptRecovery(t *testing.T) {
+	tests := []struct {
+		recovery types.InterruptAction
+		want     types.RecoveryAction
+	}{
+		{"", ""},
+		{types.InterruptResume, types.RecoveryRetry},
+		{types.InterruptReset, types.RecoveryFixState},
+		{types.InterruptBlock, types.RecoveryManual},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.recovery), func(t *testing.T) {
+			p := validChild("Login")
+			p.Attempts = []Attempt{
+				{Iteration: 1, Outcome: types.OutcomeInterrupted, Recovery: tt.recovery},
+				{Iteration: 2, Outcome: types.OutcomePartial},
+			}
+			if got := EntryFromAttempt(p, &p.Attempts[0]).Context.RecoveryAction; got != "" {
+				t.Errorf("interrupted entry RecoveryAction = %q, want it on the next iteration", got)
+			}
+			if got := EntryFromAttempt(p, &p.Attempts[1]).Context.RecoveryAction; got != tt.want {
+				t.Errorf("RecoveryAction = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestAppendEntry(t *testing.T) {
+	p := NewProgress()
+	entry := ProgressEntry{PRDID: "auth", Iteration: 1, Status: types.ProgressPartial}
+
+	if err := p.AppendEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	if p.Entries[0].ID != "auth-1" {
+		t.Errorf("ID = %q, want auth-1", p.Entries[0].ID)
+	}
+	if err := p.AppendEntry(entry); !errors.Is(err, ErrEntryExists) {
+		t.Errorf("second append error = %v, want ErrEntryExists", err)
+	}
+	if err := p.AppendEntry(ProgressEntry{PRDID: "auth", Iteration: 2, Status: "done"}); err == nil {
+		t.Error("append with invalid status succeeded")
+	}
+}
+
+func TestValidateProgressFileAppendOnly(t *testing.T) {
+	before := NewProgress()
+	if err := before.AppendEntry(ProgressEntry{PRDID: "auth", Iteration: 1, Status: types.ProgressPartial, Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	appended := *before
+	appended.Entries = append(append([]ProgressEntry{}, before.Entries...), ProgressEntry{
+		ID: "auth-2", PRDID: "auth", Iteration: 2, Status: types.ProgressCompleted, Timestamp: time.Now(), Observations: []ProgressObs{},
+	})
+
+	rewritten := *before
+	rewritten.Entries = append([]ProgressEntry{}, before.Entries...)
+	rewritten.Entries[0].Summary = "changed history"
+
+	removed := *before
+	removed.Entries = []ProgressEntry{}
+
+	tests := []struct {
+		name    string
+		after   *Progress
+		wantErr bool
+	}{
+		{"unchanged", before, false},
+		{"appended", &appended, false},
+		{"rewritten", &rewritten, true},
+		{"removed", &removed, true},
+	}
+
+	dir := t.TempDir()
+	beforePath := filepath.Join(dir, "before.json")
+	if err := before.Save(beforePath); err != nil {
+		t.Fatal(err)
+	}
+	beforeData, err := os.ReadFile(beforePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := tt.after.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			errs := ValidateProgressFile(path, beforeData)
+			if errs.HasErrors() != tt.wantErr {
+				t.Errorf("HasErrors() = %v, want %v: %s", errs.HasErrors(), tt.wantErr, errs.Error())
+			}
+		})
+	}
+}
+
+func TestRestoreProgress(t *testing.T) {
+	now := time.Now()
+	before := NewProgress()
+	if err := before.AppendEntry(ProgressEntry{PRDID: "auth", Iteration: 1, Status: types.ProgressPartial, Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	before.AddLearning(Learning{Content: "Run make gen first", SourcePRDID: "billing", CreatedAt: now})
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another worker recorded billing's iteration and a learning; auth's
+	// iteration rewrote history and added entries of its own
+	current, err := parseProgress(beforeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Entries[0].Summary = "rewritten history"
+	for _, e := range []ProgressEntry{
+		{PRDID: "billing", Iteration: 1, Status: types.ProgressCompleted, Timestamp: now},
+		{PRDID: "auth", Iteration: 2, Status: types.ProgressCompleted, Timestamp: now},
+	} {
+		if err := current.AppendEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current.AddLearning(Learning{Content: "Stripe needs a test key", SourcePRDID: "billing", CreatedAt: now})
+	current.AddLearning(Learning{Content: "Skip the tests", SourcePRDID: "auth", CreatedAt: now})
+
+	tests := []struct {
+		name          string
+		current       string
+		wantEntries   []string
+		wantLearnings int
+	}{
+		{"keeps other workers' additions", mustJSON(t, current), []string{"auth-1", "billing-1"}, 2},
+		{"unparseable file gets the snapshot", `{"entries": [`, []string{"auth-1"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "progress.json")
+			if err := os.WriteFile(path, []byte(tt.current), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := RestoreProgress(path, beforeData, "auth"); err != nil {
+				t.Fatalf("RestoreProgress() error = %v", err)
+			}
+			if errs := ValidateProgressFile(path, beforeData); errs.HasErrors() {
+				t.Errorf("restored progress is invalid: %s", errs.Error())
+			}
+			restored, err := LoadProgress(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, e := range restored.Entries {
+				ids = append(ids, e.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantEntries) {
+				t.Errorf("entries = %v, want %v", ids, tt.wantEntries)
+			}
+			if len(restored.Learnings) != tt.wantLearnings {
+				t.Errorf("learnings = %+v, want %d", restored.Learnings, tt.wantLearnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package prd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of progress records returned by Query
+const (
+	KindEntry       = "entry"
+	KindObservation = "observation"
+	KindLearning    = "learning"
+	KindPattern     = "pattern"
+)
+
+// QueryKinds lists the record kinds Query can filter on
+var QueryKinds = []string{KindEntry, KindObservation, KindLearning, KindPattern}
+
+// Query filters progress records. Zero fields match everything.
+type Query struct {
+	Kinds    []string  // record kinds to include
+	PRDID    string    // PRD the record came from
+	Category string    // observation category, learning type, pattern type or entry status
+	Since    time.Time // inclusive
+	Until    time.Time // exclusive
+	Text     string    // case-insensitive substring of the record's text or scope
+}
+
+// QueryResult is one matching progress record, flattened for display
+type QueryResult struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"` // entry, learning or pattern ID; observations use their entry's ID
+	PRDID     string    `json:"prd_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Category  string    `json:"category,omitempty"`
+	Text      string    `json:"text"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// Query returns the records matching q, oldest first
+func (p *Progress) Query(q Query) []QueryResult {
+	return q.run(p.Entries, p.Learnings, p.Patterns)
+}
+
+// QueryEntries runs q over archived entries, which carry no learnings or patterns
+func QueryEntries(entries []ProgressEntry, q Query) []QueryResult {
+	return q.run(entries, nil, nil)
+}
+
+func (q Query) run(entries []ProgressEntry, learnings []Learning, patterns []Pattern) []QueryResult {
+	var results []QueryResult
+	add := func(r QueryResult, scope ...string) {
+		if q.matches(r, scope) {
+			results = append(results, r)
+		}
+	}
+
+	for _, e := range entries {
+		add(QueryResult{
+			Kind: KindEntry, ID: e.ID, PRDID: e.PRDID, Timestamp: e.Timestamp,
+			Category: string(e.Status), Text: e.Summary,
+		}, e.FilesModified...)
+		for _, o := range e.Observations {
+			add(QueryResult{
+				Kind: KindObservation, ID: e.ID, PRDID: e.PRDID, Timestamp: e.Timestamp,
+				Category: string(o.Category), Text: o.Title, Detail: o.Description,
+			}, o.File)
+		}
+	}
+	for _, l := range learnings {
+		add(QueryResult{
+			Kind: KindLearning, ID: l.ID, PRDID: l.SourcePRDID, Timestamp: l.CreatedAt,
+			Category: string(l.Type), Text: l.Content, Detail: l.Context,
+		}, l.AppliesTo...)
+	}
+	for _, pat := range patterns {
+		add(QueryResult{
+			Kind: KindPattern, ID: pat.ID, PRDID: pat.SourcePRDID, Timestamp: pat.DiscoveredAt,
+			Category: string(pat.Type), Text: pat.Name, Detail: pat.Description,
+		}, pat.Examples...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.Before(results[j].Timestamp)
+	})
+	return results
+}
+
+func (q Query) matches(r QueryResult, scope []string) bool {
+	if len(q.Kinds) > 0 && !contains(q.Kinds, r.Kind) {
+		return false
+	}
+	if q.PRDID != "" && r.PRDID != q.PRDID {
+		return false
+	}
+	if q.Category != "" && !strings.EqualFold(r.Category, q.Category) {
+		return false
+	}
+	if !q.Since.IsZero() && r.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.Text != "" {
+		haystack := strings.ToLower(strings.Join(append([]string{r.Text, r.Detail}, scope...), "\n"))
+		if !strings.Contains(haystack, strings.ToLower(q.Text)) {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadArchivedEntries reads every archive written by compaction in dir,
+// returning their entries oldest first. A missing directory yields none.
+func LoadArchivedEntries(dir string) ([]ProgressEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "progress-*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	sort.Strings(paths)
+
+	var entries []ProgressEntry
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var archive Archive
+		if err := json.Unmarshal(data, &archive); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		entries = append(entries, archive.Entries...)
+	}
+	return entries, nil
+}
@@ -0,0 +1,59 @@
+package prd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestQuery(t *testing.T) {
+	now := time.Now()
+	p := NewProgress()
+	p.Entries = []ProgressEntry{
+		{
+			ID: "auth-1", PRDID: "auth", Iteration: 1, Status: types.ProgressFailed, Timestamp: now.Add(-10 * 24 * time.Hour),
+			Summary: "Token refresh broke", FilesModified: []string{"internal/auth/token.go"},
+			Observations: []ProgressObs{{Type: types.ObsFinding, Title: "Refresh race", Category: types.ObsCatBug}},
+		},
+		{
+			ID: "billing-1", PRDID: "billing", Iteration: 1, Status: types.ProgressCompleted, Timestamp: now.Add(-2 * 24 * time.Hour),
+			Observations: []ProgressObs{{Type: types.ObsFinding, Title: "Rounding error in invoices", Category: types.ObsCatBug}},
+		},
+	}
+	p.Learnings = []Learning{
+		{ID: "learning-0001", Content: "Tokens are RS256", AppliesTo: []string{"internal/auth"}, SourcePRDID: "auth", CreatedAt: now.Add(-9 * 24 * time.Hour)},
+		{ID: "learning-0002", Content: "Stripe amounts are cents", SourcePRDID: "billing", CreatedAt: now},
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string // kind:id in order
+	}{
+		{"learnings about auth", Query{Kinds: []string{KindLearning}, Text: "auth"}, []string{"learning:learning-0001"}},
+		{"bugs last week", Query{Kinds: []string{KindObservation}, Category: "bug", Since: now.Add(-7 * 24 * time.Hour)}, []string{"observation:billing-1"}},
+		{"by PRD", Query{PRDID: "auth"}, []string{"entry:auth-1", "observation:auth-1", "learning:learning-0001"}},
+		{"status category", Query{Category: "completed"}, []string{"entry:billing-1"}},
+		{"until", Query{Kinds: []string{KindEntry}, Until: now.Add(-5 * 24 * time.Hour)}, []string{"entry:auth-1"}},
+		{"text in file scope", Query{Text: "token.go"}, []string{"entry:auth-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range p.Query(tt.query) {
+				got = append(got, r.Kind+":"+r.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
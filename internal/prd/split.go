@@ -0,0 +1,115 @@
+package prd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+// Split inserts children after the parent to take over its remaining work.
+// Children inherit the parent's verification, and its related files and tags
+// when they set none. They run in order, the first after the parent's own
+// dependencies, and link back via Parent. The parent is blocked until every
+// child is complete; see FinishSplit. Nothing is changed if any child is
+// invalid.
+func (b *Backlog) Split(parentID string, children []*PRD) error {
+	parent := b.Find(parentID)
+	if parent == nil {
+		return fmt.Errorf("PRD %s not found in backlog", parentID)
+	}
+	if parent.Status == types.StatusComplete {
+		return fmt.Errorf("PRD %s is already complete", parentID)
+	}
+	if len(children) < 2 {
+		return fmt.Errorf("splitting %s needs at least 2 children, got %d", parentID, len(children))
+	}
+
+	deps := parent.DependsOn
+	ids := make([]string, len(children))
+	conditions := make([]UnblockCondition, len(children))
+	errs := &types.ValidationErrors{}
+	for i, child := range children {
+		child.Parent = parent.ID
+		child.Verification = parent.Verification
+		if len(child.RelatedFiles) == 0 {
+			child.RelatedFiles = append([]string{}, parent.RelatedFiles...)
+		}
+		if len(child.Tags) == 0 {
+			child.Tags = append([]string{}, parent.Tags...)
+		}
+		child.DependsOn = union(child.DependsOn, deps)
+		deps = []string{child.ID}
+
+		field := fmt.Sprintf("children[%d]", i)
+		if b.Find(child.ID) != nil || contains(ids[:i], child.ID) {
+			errs.Add(field+".id", "unique PRD ID", child.ID, "Give the child an ID not used elsewhere in the backlog")
+		}
+		errs.Merge(field, child.ValidateWithDetails())
+
+		ids[i] = child.ID
+		conditions[i] = UnblockCondition{PRDComplete: child.ID}
+	}
+	if errs.HasErrors() {
+		return fmt.Errorf("invalid split of %s: %w", parentID, errs)
+	}
+
+	features := make([]*PRD, 0, len(b.Features)+len(children))
+	for _, p := range b.Features {
+		features = append(features, p)
+		if p == parent {
+			features = append(features, children...)
+		}
+	}
+	b.Features = features
+
+	parent.UnblockWhen = conditions
+	parent.SetStatus(types.StatusBlocked)
+	if attempt := parent.LastAttempt(); attempt != nil {
+		attempt.Observations = append(attempt.Observations, "split into: "+strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+// Children returns the PRDs split off from id, in backlog order
+func (b *Backlog) Children(id string) []*PRD {
+	var children []*PRD
+	for _, p := range b.Features {
+		if p != nil && p.Parent == id {
+			children = append(children, p)
+		}
+	}
+	return children
+}
+
+// FinishSplit hands a split PRD whose children are all complete to review.
+// The children did its remaining work, so instead of running it again a
+// completed attempt is recorded for the review to check against its own
+// acceptance criteria. That attempt also resets its bailout count, and a
+// used-up budget is extended in case the review sends it back. Returns
+// false, changing nothing, when p has no children or one isn't complete.
+func (b *Backlog) FinishSplit(p *PRD) bool {
+	children := b.Children(p.ID)
+	if len(children) == 0 {
+		return false
+	}
+	ids := make([]string, len(children))
+	for i, child := range children {
+		if child.Status != types.StatusComplete {
+			return false
+		}
+		ids[i] = child.ID
+	}
+
+	attempt := p.StartAttempt("")
+	attempt.EndedAt = time.Now()
+	attempt.Outcome = types.OutcomeComplete
+	attempt.Observations = []string{"completed by split children: " + strings.Join(ids, ", ")}
+	if p.IterationsExhausted() {
+		p.ExtendIterations(DefaultMaxIterations)
+	}
+	p.UnblockWhen = nil
+	p.SetStatus(types.StatusPendingReview)
+	return true
+}
@@ -0,0 +1,114 @@
+package prd
+
+import (
+	"testing"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func validChild(title string) *PRD {
+	p := NewPRD(title)
+	p.Description = title
+	p.AcceptanceCriteria = []string{title + " works"}
+	p.Steps = []string{"implement " + title}
+	return p
+}
+
+func TestBacklogSplit(t *testing.T) {
+	parent := validChild("Big feature")
+	parent.DependsOn = []string{"setup"}
+	parent.Verification = Verification{Tests: []string{"go test ./..."}}
+	parent.Tags = []string{"api"}
+	parent.Status = types.StatusInProgress
+	after := validChild("After")
+	backlog := &Backlog{Features: []*PRD{parent, after}}
+
+	first, second := validChild("Part one"), validChild("Part two")
+	if err := backlog.Split(parent.ID, []*PRD{first, second}); err != nil {
+		t.Fatal(err)
+	}
+
+	order := []*PRD{parent, first, second, after}
+	for i, p := range backlog.Features {
+		if p != order[i] {
+			t.Fatalf("features[%d] = %s, want %s", i, p.ID, order[i].ID)
+		}
+	}
+	if parent.Status != types.StatusBlocked || len(parent.UnblockWhen) != 2 {
+		t.Errorf("parent status %s with %d conditions, want blocked with 2", parent.Status, len(parent.UnblockWhen))
+	}
+	if first.Parent != parent.ID || first.Verification.Tests[0] != "go test ./..." || first.Tags[0] != "api" {
+		t.Errorf("first child did not inherit from parent: %+v", first)
+	}
+	if len(first.DependsOn) != 1 || first.DependsOn[0] != "setup" {
+		t.Errorf("first child depends on %v, want [setup]", first.DependsOn)
+	}
+	if len(second.DependsOn) != 1 || second.DependsOn[0] != first.ID {
+		t.Errorf("second child depends on %v, want [%s]", second.DependsOn, first.ID)
+	}
+}
+
+func TestBacklogSplitRejects(t *testing.T) {
+	invalid := NewPRD("No steps")
+
+	tests := []struct {
+		name     string
+		children []*PRD
+	}{
+		{"single child", []*PRD{validChild("Only")}},
+		{"invalid child", []*PRD{validChild("Fine"), invalid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := validChild("Big feature")
+			backlog := &Backlog{Features: []*PRD{parent}}
+			if err := backlog.Split(parent.ID, tt.children); err == nil {
+				t.Fatal("expected error")
+			}
+			if len(backlog.Features) != 1 || parent.Status != types.StatusPending {
+				t.Errorf("backlog changed on failed split")
+			}
+		})
+	}
+}
+
+func TestBacklogFinishSplit(t *testing.T) {
+	parent := validChild("Big feature")
+	parent.MaxIterations = 3
+	// Three bailouts led to the split
+	for i := 0; i < 3; i++ {
+		parent.StartAttempt("")
+		parent.LastAttempt().Outcome = types.OutcomePartial
+	}
+	backlog := &Backlog{Features: []*PRD{parent}}
+	first, second := validChild("Part one"), validChild("Part two")
+	if err := backlog.Split(parent.ID, []*PRD{first, second}); err != nil {
+		t.Fatal(err)
+	}
+
+	first.Status = types.StatusComplete
+	if backlog.FinishSplit(parent) || parent.Status != types.StatusBlocked {
+		t.Fatalf("FinishSplit() finished with a child still %s", second.Status)
+	}
+	if backlog.FinishSplit(first) {
+		t.Error("FinishSplit() finished a PRD without children")
+	}
+
+	second.Status = types.StatusComplete
+	if !backlog.FinishSplit(parent) {
+		t.Fatal("FinishSplit() = false with every child complete")
+	}
+	if parent.Status != types.StatusPendingReview || len(parent.UnblockWhen) != 0 {
+		t.Errorf("parent status %s with %d conditions, want pending_review with none", parent.Status, len(parent.UnblockWhen))
+	}
+	if got := parent.LastAttempt().Outcome; got != types.OutcomeComplete {
+		t.Errorf("last attempt outcome = %s, want complete", got)
+	}
+	if got := parent.ConsecutiveBailouts(); got != 0 {
+		t.Errorf("ConsecutiveBailouts() = %d, want 0", got)
+	}
+	if parent.IterationsExhausted() {
+		t.Errorf("parent has no iterations left (%d/%d) if the review sends it back", parent.CurrentIteration, parent.MaxIterations)
+	}
+}
@@ -74,6 +74,24 @@ func (p *PRD) ValidateWithDetails() *types.ValidationErrors {
 	if p.MaxIterations <= 0 {
 		errs.Add("max_iterations", "positive integer", p.MaxIterations, "Set max_iterations to a positive value (e.g., 3)")
 	}
+	if !p.Escalation.IsValid() {
+		errs.Add(
+			"escalation",
+			fmt.Sprintf("one of: %v", types.AllEscalationPolicies()),
+			p.Escalation,
+			"Remove escalation to use the configured policy, or pick a valid one",
+		)
+	}
+	for i, c := range p.UnblockWhen {
+		if c.fieldsSet() != 1 {
+			errs.Add(
+				fmt.Sprintf("unblock_when[%d]", i),
+				"exactly one of: command, file_exists, prd_complete",
+				c,
+				"Split the condition so each entry sets a single field",
+			)
+		}
+	}
 
 	return errs
 }
@@ -0,0 +1,89 @@
+// Package proc stops the process groups Ralph starts, so servers and
+// watchers a session leaves behind don't outlive it.
+package proc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// pollInterval is how often KillGroup checks whether the group has exited
+const pollInterval = 100 * time.Millisecond
+
+// Process is a running process in a group
+type Process struct {
+	PID     int
+	Command string
+}
+
+func (p Process) String() string {
+	return fmt.Sprintf("%d %s", p.PID, p.Command)
+}
+
+// Group lists the live processes in process group pgid. Zombies, which
+// have exited but not been reaped by their parent, are left out.
+func Group(pgid int) ([]Process, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,pgid=,stat=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	return parsePS(out, pgid), nil
+}
+
+// parsePS extracts the live members of pgid from ps output with the
+// columns pid, pgid, stat and args
+func parsePS(out []byte, pgid int) []Process {
+	var procs []Process
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) < 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		group, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil || group != pgid || strings.HasPrefix(fields[2], "Z") {
+			continue
+		}
+		procs = append(procs, Process{PID: pid, Command: strings.Join(fields[3:], " ")})
+	}
+	return procs
+}
+
+// KillGroup stops every process in group pgid: SIGTERM first, then SIGKILL
+// for whatever is still running after grace. It returns the processes that
+// were running when it was called. When the group can't be listed it is
+// sent SIGKILL straight away.
+func KillGroup(pgid int, grace time.Duration) ([]Process, error) {
+	procs, err := Group(pgid)
+	if err != nil {
+		return nil, signal(pgid, syscall.SIGKILL)
+	}
+	if len(procs) == 0 {
+		return nil, nil
+	}
+
+	if err := signal(pgid, syscall.SIGTERM); err != nil {
+		return procs, err
+	}
+	for deadline := time.Now().Add(grace); time.Now().Before(deadline); time.Sleep(pollInterval) {
+		if left, err := Group(pgid); err == nil && len(left) == 0 {
+			return procs, nil
+		}
+	}
+	return procs, signal(pgid, syscall.SIGKILL)
+}
+
+// signal sends sig to group pgid; a group that is already gone isn't an error
+func signal(pgid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pgid, sig)
+	if err == nil || errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return fmt.Errorf("failed to send %s to process group %d: %w", sig, pgid, err)
+}
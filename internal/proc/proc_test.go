@@ -0,0 +1,90 @@
+package proc
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParsePS(t *testing.T) {
+	out := []byte(`    1     1 Ss   /sbin/init
+  200   200 S    claude -p do things
+  201   200 S    npm run dev
+  202   200 Z    [node] <defunct>
+  203   201 S    vite --port 5173
+  bad   200 S    garbage
+`)
+	tests := []struct {
+		name string
+		pgid int
+		want []Process
+	}{
+		{"group members without zombies", 200, []Process{{200, "claude -p do things"}, {201, "npm run dev"}}},
+		{"single member", 201, []Process{{203, "vite --port 5173"}}},
+		{"no members", 999, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePS(out, tt.pgid)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parsePS() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parsePS()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKillGroup(t *testing.T) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		t.Skip("ps not available")
+	}
+
+	// A background child that ignores SIGTERM forces the SIGKILL escalation
+	cmd := exec.Command("sh", "-c", "(trap '' TERM; sleep 30) & sleep 30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pgid := cmd.Process.Pid
+	go cmd.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		procs, err := Group(pgid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(procs) >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("group never started: %v", procs)
+		}
+		time.Sleep(pollInterval)
+	}
+
+	reaped, err := KillGroup(pgid, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("KillGroup() error = %v", err)
+	}
+	if len(reaped) < 3 {
+		t.Errorf("KillGroup() reaped %v, want the shell and both children", reaped)
+	}
+
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		left, _ := Group(pgid)
+		if len(left) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("processes survived KillGroup: %v", left)
+		}
+		time.Sleep(pollInterval)
+	}
+}
@@ -0,0 +1,150 @@
+// Package repair feeds validation errors in Ralph's JSON state files back
+// to Claude so it can fix its own mistakes, restoring the last good copy
+// when it cannot.
+package repair
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/llm"
+	"github.com/daydemir/ralph/internal/prompts"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/utils"
+)
+
+// repairTools limits repair sessions to reading and editing files
+var repairTools = []string{"Read", "Edit", "Write"}
+
+// ErrRestored is returned when repair failed and the file was restored
+// from its pre-iteration snapshot
+var ErrRestored = errors.New("repair failed, restored pre-iteration copy")
+
+// File is a state file to keep valid
+type File struct {
+	Path     string
+	Before   []byte                         // contents before the iteration; nil if the file did not exist
+	Validate func() *types.ValidationErrors // validates the file as currently on disk
+	Schema   []byte                         // JSON Schema for the file, shown to Claude when set
+	Restore  func() error                   // puts back the pre-iteration state; Before is written back whole when nil
+}
+
+// Repairer runs short Claude sessions to fix invalid state files
+type Repairer struct {
+	WorkspaceDir string
+	Claude       *llm.Claude
+	Model        string
+	MaxAttempts  int
+	Display      *display.Display
+
+	session func(ctx context.Context, f File, errs *types.ValidationErrors) error // runs one repair session; runSession when nil
+}
+
+// Snapshot reads the current contents of path for later restoration.
+// Returns nil when the file does not exist.
+func Snapshot(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// Ensure validates f and, if invalid, runs up to MaxAttempts repair
+// sessions, stopping early when ctx is cancelled. If the file is still
+// invalid afterwards it is restored from f.Before and ErrRestored is
+// returned.
+func (r *Repairer) Ensure(ctx context.Context, f File) error {
+	errs := f.Validate()
+	if !errs.HasErrors() {
+		return nil
+	}
+
+	session := r.session
+	if session == nil {
+		session = r.runSession
+	}
+	for attempt := 1; attempt <= r.MaxAttempts && ctx.Err() == nil; attempt++ {
+		r.Display.Warning(fmt.Sprintf("%s failed validation (%d errors), repair attempt %d/%d",
+			f.Path, len(errs.Errors), attempt, r.MaxAttempts))
+
+		if err := session(ctx, f, errs); err != nil {
+			r.Display.Warning(fmt.Sprintf("repair session failed: %v", err))
+		}
+
+		errs = f.Validate()
+		if !errs.HasErrors() {
+			r.Display.Success(fmt.Sprintf("%s repaired", f.Path))
+			return nil
+		}
+	}
+
+	if err := restore(f); err != nil {
+		return err
+	}
+	r.Display.Error(fmt.Sprintf("%s could not be repaired, restored pre-iteration copy", f.Path))
+	return fmt.Errorf("%s: %w: %s", f.Path, ErrRestored, errs.Error())
+}
+
+func (r *Repairer) runSession(ctx context.Context, f File, errs *types.ValidationErrors) error {
+	base, err := prompts.GetForWorkspace(r.WorkspaceDir, "repair.md")
+	if err != nil {
+		return fmt.Errorf("failed to load repair prompt: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base)
+	sb.WriteString("\n<file>\n" + f.Path + "\n</file>\n")
+	if len(f.Schema) > 0 {
+		sb.WriteString("\n<schema>\n" + string(f.Schema) + "\n</schema>\n")
+	}
+	sb.WriteString("\n<validation-errors>\n" + errs.ToPrompt() + "</validation-errors>\n")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	handler := llm.NewConsoleHandlerWithTerminate(r.Display, cancel)
+	reader, err := r.Claude.Execute(ctx, llm.ExecuteOptions{
+		Prompt:       sb.String(),
+		Model:        r.Model,
+		AllowedTools: repairTools,
+		WorkDir:      r.WorkspaceDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	parseErr := llm.ParseStream(reader, handler, cancel)
+	closeErr := reader.Close()
+	if parseErr != nil {
+		return parseErr
+	}
+	if closeErr != nil && ctx.Err() == nil {
+		return closeErr
+	}
+	return nil
+}
+
+// restore writes the snapshot back, removing the file if it did not exist before
+func restore(f File) error {
+	if f.Restore != nil {
+		return f.Restore()
+	}
+	if f.Before == nil {
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", f.Path, err)
+		}
+		return nil
+	}
+	if err := utils.WriteFileAtomic(f.Path, f.Before, 0644); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repair
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestEnsure(t *testing.T) {
+	tests := []struct {
+		name         string
+		written      string   // the file as the iteration left it
+		sessions     []string // what each repair session writes
+		maxAttempts  int
+		cancelled    bool
+		wantSessions int
+		wantRestored bool
+		wantContent  string
+	}{
+		{"valid on first try", "valid", nil, 2, false, 0, false, "valid"},
+		{"fixed on retry", "broken", []string{"still broken", "valid"}, 3, false, 2, false, "valid"},
+		{"retries exhausted", "broken", []string{"still broken", "worse"}, 2, false, 2, true, "before"},
+		{"no attempts allowed", "broken", nil, 0, false, 0, true, "before"},
+		{"cancelled", "broken", []string{"valid"}, 2, true, 0, true, "before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(path, []byte(tt.written), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := 0
+			r := &Repairer{
+				MaxAttempts: tt.maxAttempts,
+				Display:     display.New(),
+				session: func(ctx context.Context, f File, errs *types.ValidationErrors) error {
+					if !errs.HasErrors() {
+						t.Error("repair session started without validation errors")
+					}
+					calls++
+					return os.WriteFile(f.Path, []byte(tt.sessions[calls-1]), 0644)
+				},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			err := r.Ensure(ctx, File{Path: path, Before: []byte("before"), Validate: validateContent(path)})
+			if restored := errors.Is(err, ErrRestored); restored != tt.wantRestored {
+				t.Fatalf("Ensure() error = %v, wantRestored %v", err, tt.wantRestored)
+			}
+			if !tt.wantRestored && err != nil {
+				t.Fatalf("Ensure() error = %v", err)
+			}
+			if calls != tt.wantSessions {
+				t.Errorf("repair sessions = %d, want %d", calls, tt.wantSessions)
+			}
+			if got := readFile(t, path); got != tt.wantContent {
+				t.Errorf("file = %q, want %q", got, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestEnsureRestore(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     []byte
+		restore    bool
+		wantExists bool
+	}{
+		{"writes the snapshot back", []byte("before"), false, true},
+		{"removes a file that did not exist", nil, false, false},
+		{"uses the file's own restore", []byte("before"), true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(path, []byte("broken"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			f := File{Path: path, Before: tt.before, Validate: validateContent(path)}
+			want := string(tt.before)
+			if tt.restore {
+				want = "merged"
+				f.Restore = func() error { return os.WriteFile(path, []byte(want), 0644) }
+			}
+
+			r := &Repairer{Display: display.New()}
+			if err := r.Ensure(context.Background(), f); !errors.Is(err, ErrRestored) {
+				t.Fatalf("Ensure() error = %v, want ErrRestored", err)
+			}
+			_, err := os.Stat(path)
+			if exists := err == nil; exists != tt.wantExists {
+				t.Fatalf("file exists = %v, want %v", exists, tt.wantExists)
+			}
+			if tt.wantExists {
+				if got := readFile(t, path); got != want {
+					t.Errorf("file = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+// validateContent accepts the file at path only when it reads "valid"
+func validateContent(path string) func() *types.ValidationErrors {
+	return func() *types.ValidationErrors {
+		errs := &types.ValidationErrors{}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "valid" {
+			errs.Add("content", "valid", string(data), "file is not valid")
+		}
+		return errs
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
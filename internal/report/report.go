@@ -0,0 +1,250 @@
+// Package report renders the backlog and progress history as a Markdown or
+// self-contained HTML document for humans.
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/daydemir/ralph/internal/logs"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+//go:embed report.md.tmpl
+var markdownTemplate string
+
+//go:embed report.html.tmpl
+var htmlTemplate string
+
+// statusOrder lists statuses in the order the backlog section shows them
+var statusOrder = []types.Status{
+	types.StatusInProgress, types.StatusPendingReview, types.StatusBlocked,
+	types.StatusPending, types.StatusComplete,
+}
+
+// Input is what a report is built from
+type Input struct {
+	Project  string
+	Since    time.Time // attempts, completions and learnings before this are left out; zero means all
+	Backlog  *prd.Backlog
+	Progress *prd.Progress      // may be nil
+	Sessions []logs.SessionInfo // Claude sessions from the verbatim logs index, may be nil
+}
+
+// Report is the rendered view of an Input
+type Report struct {
+	Project     string
+	GeneratedAt time.Time
+	Since       time.Time
+
+	Totals      Totals
+	Statuses    []StatusGroup
+	PRDs        []PRDActivity // PRDs with attempts in the period
+	Blocked     []Blocked
+	Completions []prd.ProgressEntry
+	Learnings   []prd.Learning
+	Sessions    []logs.SessionInfo
+}
+
+// Totals aggregates the attempts in the period
+type Totals struct {
+	PRDs      int
+	Completed int // PRDs completed in the period
+	Attempts  int
+	Duration  time.Duration
+	Tokens    int
+	CostUSD   float64
+}
+
+// StatusGroup is the backlog PRDs sharing a status
+type StatusGroup struct {
+	Status types.Status
+	PRDs   []*prd.PRD
+}
+
+// PRDActivity is one PRD and its attempts in the period
+type PRDActivity struct {
+	PRD      *prd.PRD
+	Attempts []prd.Attempt
+	Duration time.Duration
+	Tokens   int
+	CostUSD  float64
+}
+
+// Blocked is a blocked PRD with what is holding it up
+type Blocked struct {
+	PRD       *prd.PRD
+	Blocker   string
+	Condition string // unblock conditions, joined
+}
+
+// Build assembles a report from the backlog, progress and logs index
+func Build(in Input) *Report {
+	r := &Report{Project: in.Project, GeneratedAt: time.Now(), Since: in.Since}
+	inPeriod := func(t time.Time) bool { return in.Since.IsZero() || !t.Before(in.Since) }
+
+	for _, status := range statusOrder {
+		group := StatusGroup{Status: status, PRDs: in.Backlog.WithStatus(status)}
+		if len(group.PRDs) > 0 {
+			r.Statuses = append(r.Statuses, group)
+		}
+	}
+
+	for _, p := range in.Backlog.Features {
+		if p == nil {
+			continue
+		}
+		r.Totals.PRDs++
+		if p.Status == types.StatusComplete && p.CompletedAt != nil && inPeriod(*p.CompletedAt) {
+			r.Totals.Completed++
+		}
+		if p.Status == types.StatusBlocked {
+			b := Blocked{PRD: p}
+			if a := p.LastAttempt(); a != nil {
+				b.Blocker = a.Blocker
+			}
+			var conditions []string
+			for _, c := range p.UnblockWhen {
+				conditions = append(conditions, c.String())
+			}
+			b.Condition = strings.Join(conditions, "; ")
+			r.Blocked = append(r.Blocked, b)
+		}
+
+		activity := PRDActivity{PRD: p}
+		for _, a := range p.Attempts {
+			if !inPeriod(a.StartedAt) {
+				continue
+			}
+			activity.Attempts = append(activity.Attempts, a)
+			activity.Duration += AttemptDuration(a)
+			activity.Tokens += a.Tokens
+			activity.CostUSD += a.CostUSD
+		}
+		if len(activity.Attempts) == 0 {
+			continue
+		}
+		r.PRDs = append(r.PRDs, activity)
+		r.Totals.Attempts += len(activity.Attempts)
+		r.Totals.Duration += activity.Duration
+		r.Totals.Tokens += activity.Tokens
+		r.Totals.CostUSD += activity.CostUSD
+	}
+
+	if in.Progress != nil {
+		for _, e := range in.Progress.Entries {
+			if e.Status == types.ProgressCompleted && inPeriod(e.Timestamp) {
+				r.Completions = append(r.Completions, e)
+			}
+		}
+		for _, l := range in.Progress.Learnings {
+			if l.StillValid && inPeriod(l.CreatedAt) {
+				r.Learnings = append(r.Learnings, l)
+			}
+		}
+		sort.SliceStable(r.Learnings, func(i, j int) bool {
+			return r.Learnings[i].TimesReferenced > r.Learnings[j].TimesReferenced
+		})
+	}
+
+	for _, s := range in.Sessions {
+		if inPeriod(s.EndTime) {
+			r.Sessions = append(r.Sessions, s)
+		}
+	}
+	return r
+}
+
+// AttemptDuration is how long an attempt ran, or zero if it never ended
+func AttemptDuration(a prd.Attempt) time.Duration {
+	if a.EndedAt.IsZero() || a.EndedAt.Before(a.StartedAt) {
+		return 0
+	}
+	return a.EndedAt.Sub(a.StartedAt)
+}
+
+// Markdown renders the report as Markdown
+func (r *Report) Markdown() (string, error) {
+	tmpl, err := texttemplate.New("report").Funcs(texttemplate.FuncMap(funcs)).Parse(markdownTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse report template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// HTML renders the report as a single HTML page with inline styles
+func (r *Report) HTML() (string, error) {
+	tmpl, err := htmltemplate.New("report").Funcs(htmltemplate.FuncMap(funcs)).Parse(htmlTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse report template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var funcs = map[string]any{
+	"duration": formatDuration,
+	"attemptDuration": func(a prd.Attempt) string {
+		return formatDuration(AttemptDuration(a))
+	},
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"tokens": formatTokens,
+	"cost": func(usd float64) string {
+		if usd == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("$%.2f", usd)
+	},
+	"cell": func(s string) string {
+		return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+	},
+	"detail": func(a prd.Attempt) string {
+		switch {
+		case a.Blocker != "":
+			return "blocked: " + a.Blocker
+		case a.Review != nil && a.Review.Summary != "":
+			return "review: " + a.Review.Summary
+		case len(a.Observations) > 0:
+			return a.Observations[len(a.Observations)-1]
+		}
+		return ""
+	},
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+func formatTokens(n int) string {
+	switch {
+	case n == 0:
+		return "-"
+	case n >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fK", float64(n)/1000)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-7 * 24 * time.Hour)
+	old, recent := now.Add(-30*24*time.Hour), now.Add(-time.Hour)
+
+	done := prd.NewPRD("Done")
+	done.SetStatus(types.StatusComplete)
+	done.Attempts = []prd.Attempt{
+		{Iteration: 1, StartedAt: old, EndedAt: old.Add(time.Minute), Outcome: types.OutcomeFailed, Tokens: 500},
+		{Iteration: 2, StartedAt: recent, EndedAt: recent.Add(90 * time.Second), Outcome: types.OutcomeComplete, Tokens: 1000, CostUSD: 0.25},
+	}
+	stuck := prd.NewPRD("Stuck")
+	stuck.SetStatus(types.StatusBlocked)
+	stuck.Attempts = []prd.Attempt{{Iteration: 1, StartedAt: recent, Outcome: types.OutcomeBlocked, Blocker: "needs an API key"}}
+	stuck.UnblockWhen = []prd.UnblockCondition{{FileExists: ".env"}}
+	idle := prd.NewPRD("Idle")
+
+	progress := prd.NewProgress()
+	progress.Entries = []prd.ProgressEntry{
+		{PRDID: done.ID, Iteration: 2, Timestamp: recent, Status: types.ProgressCompleted},
+		{PRDID: done.ID, Iteration: 1, Timestamp: old, Status: types.ProgressCompleted},
+	}
+	progress.Learnings = []prd.Learning{
+		{Content: "rarely used", CreatedAt: recent, StillValid: true},
+		{Content: "often used", CreatedAt: recent, StillValid: true, TimesReferenced: 4},
+		{Content: "stale", CreatedAt: recent},
+		{Content: "too old", CreatedAt: old, StillValid: true},
+	}
+
+	r := Build(Input{
+		Since:    since,
+		Backlog:  &prd.Backlog{Features: []*prd.PRD{done, stuck, idle}},
+		Progress: progress,
+	})
+
+	want := Totals{PRDs: 3, Completed: 1, Attempts: 2, Duration: 90 * time.Second, Tokens: 1000, CostUSD: 0.25}
+	if r.Totals != want {
+		t.Errorf("Totals = %+v, want %+v", r.Totals, want)
+	}
+	if len(r.PRDs) != 2 || len(r.PRDs[0].Attempts) != 1 {
+		t.Errorf("PRDs = %+v, want Done with one attempt and Stuck", r.PRDs)
+	}
+	if len(r.Statuses) != 3 || r.Statuses[0].Status != types.StatusBlocked {
+		t.Errorf("Statuses = %+v, want blocked, pending, complete", r.Statuses)
+	}
+	if len(r.Blocked) != 1 || r.Blocked[0].Blocker != "needs an API key" || r.Blocked[0].Condition == "" {
+		t.Errorf("Blocked = %+v", r.Blocked)
+	}
+	if len(r.Completions) != 1 {
+		t.Errorf("Completions = %d, want 1", len(r.Completions))
+	}
+	if len(r.Learnings) != 2 || r.Learnings[0].Content != "often used" {
+		t.Errorf("Learnings = %+v, want often used then rarely used", r.Learnings)
+	}
+
+	md, err := r.Markdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"## Summary", "$0.25", "blocked: needs an API key", "often used", "| 2 |"} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Markdown missing %q:\n%s", s, md)
+		}
+	}
+
+	html, err := r.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(html, "<!DOCTYPE html>") || !strings.Contains(html, "needs an API key") {
+		t.Errorf("HTML output incomplete:\n%s", html)
+	}
+}
@@ -0,0 +1,254 @@
+// Package review implements the second factor of PRD completion: after the
+// executor claims a PRD is done, its verification commands are run
+// independently and a separate reviewer session checks every acceptance
+// criterion against the diff.
+package review
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/llm"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/prompts"
+	"github.com/daydemir/ralph/internal/verify"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// maxDiffBytes caps the diff inlined into the reviewer prompt
+const maxDiffBytes = 60000
+
+// Reviewer checks pending_review PRDs before they are marked complete
+type Reviewer struct {
+	WorkspaceDir string
+	WorkDir      string // where the PRD's code lives; defaults to WorkspaceDir
+	Claude       *llm.Claude
+	Model        string
+	AllowedTools []string
+	Verifier     verify.Runner // template; EvidenceDir is set per review
+	Display      *display.Display
+}
+
+// Review runs verification and the reviewer session for a PRD.
+// The returned Review is not attached to the PRD; callers decide what to do
+// with it. The verification report is returned alongside so callers can
+// link its evidence to the attempt.
+func (r *Reviewer) Review(ctx context.Context, p *prd.PRD) (*prd.Review, *verify.Report, error) {
+	result := &prd.Review{}
+
+	r.Display.Info("Review", fmt.Sprintf("Running verification for %s", p.ID))
+	runDir := workspace.RunDir(r.WorkspaceDir, p.ID, p.CurrentIteration)
+	verifier := r.Verifier
+	verifier.EvidenceDir = filepath.Join(runDir, verify.ResultsDir)
+	report, err := verifier.Run(ctx, p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Only regressions against the pre-iteration baseline fail the PRD
+	comparison := verify.Compare(loadBaseline(runDir), report)
+	result.VerificationPassed = !comparison.HasRegressions()
+	result.NewFailures = comparison.NewFailures
+	result.FixedFailures = comparison.Fixed
+
+	if !result.VerificationPassed {
+		result.ReviewedAt = time.Now()
+		result.Summary = fmt.Sprintf("verification %s: %s", comparison.Summary(), strings.Join(comparison.NewFailures, ", "))
+		return result, report, nil
+	}
+
+	prompt, err := r.buildPrompt(p, report, comparison, diffSince(r.workDir(), diffBase(p)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.Display.Info("Review", fmt.Sprintf("Checking %d acceptance criteria", len(p.AcceptanceCriteria)))
+	output, err := r.runSession(ctx, prompt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result.Criteria, result.Passed = ParseVerdicts(output, p.AcceptanceCriteria)
+	result.ReviewedAt = time.Now()
+	result.Summary = summarize(result)
+	return result, report, nil
+}
+
+func (r *Reviewer) buildPrompt(p *prd.PRD, report *verify.Report, comparison *verify.Comparison, diff string) (string, error) {
+	agent, err := prompts.GetAgentForWorkspace(r.WorkspaceDir, "reviewer")
+	if err != nil {
+		return "", fmt.Errorf("failed to load reviewer prompt: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(agent)
+	sb.WriteString("\n\n<prd>\n")
+	sb.WriteString(fmt.Sprintf("ID: %s\nTitle: %s\n\n%s\n", p.ID, p.Title, p.Description))
+	sb.WriteString("\nAcceptance criteria:\n")
+	for i, criterion := range p.AcceptanceCriteria {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, criterion))
+	}
+	sb.WriteString("</prd>\n\n<verification>\n")
+	if len(report.Results) == 0 {
+		sb.WriteString("No verification commands defined for this PRD.\n")
+	}
+	for _, res := range report.Results {
+		status := "PASS"
+		if !res.Passed {
+			status = "FAIL"
+		}
+		sb.WriteString(fmt.Sprintf("%s [%s] %s\n", status, res.Kind, res.Command))
+		for _, name := range res.Flaky {
+			sb.WriteString(fmt.Sprintf("  flaky, ignored: %s\n", name))
+		}
+	}
+	if len(comparison.PreExisting) > 0 {
+		sb.WriteString("\nThese failures existed before the iteration started and are not the executor's responsibility:\n")
+		for _, name := range comparison.PreExisting {
+			sb.WriteString("- " + name + "\n")
+		}
+	}
+	sb.WriteString("</verification>\n\n<diff>\n")
+	sb.WriteString(diff)
+	sb.WriteString("\n</diff>\n")
+
+	return sb.String(), nil
+}
+
+func (r *Reviewer) runSession(ctx context.Context, prompt string) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	handler := llm.NewConsoleHandlerWithTerminate(r.Display, cancel)
+	r.Display.ClaudeStart()
+
+	reader, err := r.Claude.Execute(ctx, llm.ExecuteOptions{
+		Prompt:       prompt,
+		Model:        r.Model,
+		AllowedTools: r.AllowedTools,
+		WorkDir:      r.workDir(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	parseErr := llm.ParseStream(reader, handler, cancel)
+	closeErr := reader.Close()
+	if parseErr != nil {
+		return "", fmt.Errorf("failed to read reviewer output: %w", parseErr)
+	}
+	if closeErr != nil && ctx.Err() == nil {
+		return "", fmt.Errorf("reviewer session failed: %w", closeErr)
+	}
+
+	return strings.Join(handler.GetCapturedOutput(), "\n"), nil
+}
+
+var (
+	criterionPattern    = regexp.MustCompile(`###CRITERION_(MET|UNMET):(\d+):?([^#]*)###`)
+	reviewPassedPattern = regexp.MustCompile(`###REVIEW_PASSED###`)
+)
+
+// ParseVerdicts extracts per-criterion verdicts from reviewer output.
+// A criterion without a verdict is treated as unmet, and the review only
+// passes when every criterion is met and the reviewer emitted REVIEW_PASSED.
+func ParseVerdicts(output string, criteria []string) ([]prd.CriterionResult, bool) {
+	results := make([]prd.CriterionResult, len(criteria))
+	seen := make([]bool, len(criteria))
+	for i, c := range criteria {
+		results[i] = prd.CriterionResult{Criterion: c, Reason: "no verdict from reviewer"}
+	}
+
+	for _, match := range criterionPattern.FindAllStringSubmatch(output, -1) {
+		n, err := strconv.Atoi(match[2])
+		if err != nil || n < 1 || n > len(criteria) {
+			continue
+		}
+		idx := n - 1
+		seen[idx] = true
+		results[idx].Met = match[1] == "MET"
+		results[idx].Reason = strings.TrimSpace(match[3])
+	}
+
+	passed := reviewPassedPattern.MatchString(output)
+	for i := range results {
+		if !seen[i] || !results[i].Met {
+			passed = false
+		}
+	}
+	return results, passed
+}
+
+func summarize(r *prd.Review) string {
+	met := 0
+	var unmet []string
+	for i, c := range r.Criteria {
+		if c.Met {
+			met++
+			continue
+		}
+		unmet = append(unmet, fmt.Sprintf("#%d %s", i+1, c.Reason))
+	}
+	if len(unmet) == 0 {
+		return fmt.Sprintf("all %d acceptance criteria met", met)
+	}
+	return fmt.Sprintf("%d/%d criteria met; unmet: %s", met, len(r.Criteria), strings.Join(unmet, "; "))
+}
+
+// loadBaseline returns the pre-iteration verification report, or nil if none was taken
+func loadBaseline(runDir string) *verify.Report {
+	baseline, err := verify.LoadReport(filepath.Join(runDir, verify.BaselineDir, verify.ReportFile))
+	if err != nil {
+		return nil
+	}
+	return baseline
+}
+
+func (r *Reviewer) workDir() string {
+	if r.WorkDir != "" {
+		return r.WorkDir
+	}
+	return r.WorkspaceDir
+}
+
+// diffSince returns the diff of the working tree against baseCommit,
+// truncated to maxDiffBytes. Falls back to HEAD when no base is known.
+// diffBase is the commit the PRD's work started from: the base of its first
+// attempt that recorded one. Criteria are judged against every iteration's
+// changes, and a split parent's review covers what its children merged.
+func diffBase(p *prd.PRD) string {
+	for _, a := range p.Attempts {
+		if a.BaseCommit != "" {
+			return a.BaseCommit
+		}
+	}
+	return ""
+}
+
+func diffSince(workDir, baseCommit string) string {
+	ref := baseCommit
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	cmd := exec.Command("git", "diff", ref)
+	cmd.Dir = workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Sprintf("(diff unavailable: %v - inspect the changes with git yourself)", err)
+	}
+	if len(out) == 0 {
+		return "(no changes since " + ref + ")"
+	}
+	if len(out) > maxDiffBytes {
+		return string(out[:maxDiffBytes]) + "\n... (diff truncated - run `git diff " + ref + "` for the rest)"
+	}
+	return string(out)
+}
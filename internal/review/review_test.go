@@ -0,0 +1,90 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/daydemir/ralph/internal/prd"
+)
+
+func TestParseVerdicts(t *testing.T) {
+	criteria := []string{"login works", "logout works"}
+
+	testCases := []struct {
+		name       string
+		output     string
+		wantPassed bool
+		wantMet    []bool
+		wantReason string // reason for the second criterion
+	}{
+		{
+			name:       "all met and passed",
+			output:     "###CRITERION_MET:1###\n###CRITERION_MET:2###\n###REVIEW_PASSED###",
+			wantPassed: true,
+			wantMet:    []bool{true, true},
+		},
+		{
+			name:       "unmet criterion with reason",
+			output:     "###CRITERION_MET:1### ###CRITERION_UNMET:2:handler not routed### ###REVIEW_FAILED:criterion 2###",
+			wantPassed: false,
+			wantMet:    []bool{true, false},
+			wantReason: "handler not routed",
+		},
+		{
+			name:       "missing verdict is unmet",
+			output:     "###CRITERION_MET:1###\n###REVIEW_PASSED###",
+			wantPassed: false,
+			wantMet:    []bool{true, false},
+			wantReason: "no verdict from reviewer",
+		},
+		{
+			name:       "all met but no overall verdict",
+			output:     "###CRITERION_MET:1###\n###CRITERION_MET:2###",
+			wantPassed: false,
+			wantMet:    []bool{true, true},
+		},
+		{
+			name:       "out of range criterion ignored",
+			output:     "###CRITERION_MET:1###\n###CRITERION_MET:2###\n###CRITERION_UNMET:7:bogus###\n###REVIEW_PASSED###",
+			wantPassed: true,
+			wantMet:    []bool{true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, passed := ParseVerdicts(tc.output, criteria)
+			if passed != tc.wantPassed {
+				t.Errorf("passed = %v, want %v", passed, tc.wantPassed)
+			}
+			for i, want := range tc.wantMet {
+				if results[i].Met != want {
+					t.Errorf("criterion %d met = %v, want %v", i+1, results[i].Met, want)
+				}
+			}
+			if tc.wantReason != "" && results[1].Reason != tc.wantReason {
+				t.Errorf("reason = %q, want %q", results[1].Reason, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestDiffBase(t *testing.T) {
+	testCases := []struct {
+		name     string
+		attempts []prd.Attempt
+		want     string
+	}{
+		{"no attempts", nil, ""},
+		{"single attempt", []prd.Attempt{{BaseCommit: "aaa"}}, "aaa"},
+		{"first iteration's base", []prd.Attempt{{BaseCommit: "aaa"}, {BaseCommit: "bbb"}}, "aaa"},
+		{"split parent closed without a base", []prd.Attempt{{BaseCommit: "aaa"}, {}}, "aaa"},
+		{"attempts without a base", []prd.Attempt{{}, {BaseCommit: "bbb"}}, "bbb"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := diffBase(&prd.PRD{Attempts: tc.attempts}); got != tc.want {
+				t.Errorf("diffBase() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
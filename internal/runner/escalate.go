@@ -0,0 +1,211 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/prompts"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// ErrHumanReview is returned when a PRD exhausted its iterations under the
+// human_review policy and the loop should stop for a person to look at it
+var ErrHumanReview = errors.New("PRD needs human review")
+
+// splitTools lets the split planner explore the code without editing it
+var splitTools = []string{"Read", "Glob", "Grep"}
+
+var childrenPattern = regexp.MustCompile(`(?s)<prds>\s*(.*?)\s*</prds>`)
+
+// childSpec is one smaller PRD proposed by the split planner
+type childSpec struct {
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	AcceptanceCriteria []string `json:"acceptance_criteria"`
+	Steps              []string `json:"steps"`
+	RelatedFiles       []string `json:"related_files"`
+}
+
+// selectNext returns the next PRD to run after re-checking blocked PRDs,
+// reviewing pending claims and escalating PRDs that are out of iterations.
+// Blocked PRDs go first, since a finished split sends its parent to review.
+// Returns nil when nothing is runnable.
+func (r *Runner) selectNext(ctx context.Context) (*prd.PRD, *prd.Backlog, error) {
+	if err := r.CheckBlocked(ctx); err != nil {
+		return nil, nil, err
+	}
+	if err := r.ReviewPending(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return nil, nil, err
+		}
+		next := backlog.Next()
+		if next == nil || !next.IterationsExhausted() {
+			return next, backlog, nil
+		}
+		// Every policy leaves the PRD blocked or with a fresh budget, so this terminates
+		if err := r.Escalate(ctx, next.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Escalate applies the escalation policy to a PRD that has used all of its
+// iterations. Policies that cannot apply fall back to blocking the PRD.
+func (r *Runner) Escalate(ctx context.Context, prdID string) error {
+	backlog, err := r.loadBacklog()
+	if err != nil {
+		return err
+	}
+	p := backlog.Find(prdID)
+	if p == nil {
+		return fmt.Errorf("PRD %s not found in backlog", prdID)
+	}
+
+	policy := p.Escalation
+	if policy == "" {
+		policy = r.cfg.Build.Escalation
+	}
+	r.display.Warning(fmt.Sprintf("%s used %d/%d iterations, escalating: %s",
+		p.ID, p.CurrentIteration, p.MaxIterations, policy))
+
+	reason := fmt.Sprintf("max iterations reached (%d/%d)", p.CurrentIteration, p.MaxIterations)
+	if policy == types.EscalateSplit {
+		children, err := r.Split(ctx, p.ID)
+		if err == nil {
+			r.display.Success(fmt.Sprintf("%s split into %s", p.ID, strings.Join(children, ", ")))
+			return nil
+		}
+		r.display.Warning(fmt.Sprintf("split failed: %v", err))
+		reason += "; split failed"
+	}
+
+	return r.withState(func() error {
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		p := backlog.Find(prdID)
+		if p == nil {
+			return fmt.Errorf("PRD %s was removed from the backlog during escalation", prdID)
+		}
+
+		switch policy {
+		case types.EscalateStrongerModel:
+			model := r.cfg.Build.EscalationModel
+			if p.Model != model && r.model != model {
+				note(p, fmt.Sprintf("escalated: %s, retrying with %s", reason, model))
+				p.Model = model
+				p.ExtendIterations(prd.DefaultMaxIterations)
+				r.display.Info("Escalation", fmt.Sprintf("%s gets %d more iterations on %s", p.ID, prd.DefaultMaxIterations, model))
+				return backlog.Save(workspace.PRDPath(r.workspaceDir))
+			}
+			reason += " on " + model
+		case types.EscalateHumanReview:
+			block(p, "needs human review: "+reason)
+			if err := backlog.Save(workspace.PRDPath(r.workspaceDir)); err != nil {
+				return err
+			}
+			r.display.Warning(fmt.Sprintf("%s needs human review; run 'ralph unblock %s' once resolved", p.ID, p.ID))
+			return fmt.Errorf("%s: %w", p.ID, ErrHumanReview)
+		}
+
+		block(p, reason)
+		r.display.Info("Blocked", fmt.Sprintf("%s: %s", p.ID, reason))
+		return backlog.Save(workspace.PRDPath(r.workspaceDir))
+	})
+}
+
+// Split runs a planning session that breaks a PRD into smaller child PRDs
+// and adds them to the backlog. Returns the IDs of the children. The planner
+// runs without the state lock; the children are added to a freshly loaded
+// backlog.
+func (r *Runner) Split(ctx context.Context, prdID string) ([]string, error) {
+	backlog, err := r.loadBacklog()
+	if err != nil {
+		return nil, err
+	}
+	p := backlog.Find(prdID)
+	if p == nil {
+		return nil, fmt.Errorf("PRD %s not found in backlog", prdID)
+	}
+
+	base, err := prompts.GetForWorkspace(r.workspaceDir, "split.md")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load split prompt: %w", err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal PRD: %w", err)
+	}
+	prompt := base + "\n<prd>\n" + string(data) + "\n</prd>\n"
+
+	handler, err := r.execute(ctx, prompt, r.model, splitTools)
+	if err != nil {
+		return nil, err
+	}
+	match := childrenPattern.FindStringSubmatch(strings.Join(handler.GetCapturedOutput(), "\n"))
+	if match == nil {
+		return nil, fmt.Errorf("split planner did not produce a <prds> section")
+	}
+	var specs []childSpec
+	if err := json.Unmarshal([]byte(match[1]), &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse split planner output: %w", err)
+	}
+
+	children := make([]*prd.PRD, len(specs))
+	for i, spec := range specs {
+		child := prd.NewPRD(spec.Title)
+		child.Description = spec.Description
+		child.AcceptanceCriteria = spec.AcceptanceCriteria
+		child.Steps = spec.Steps
+		child.RelatedFiles = spec.RelatedFiles
+		children[i] = child
+	}
+
+	err = r.withState(func() error {
+		// Reload in case the backlog changed while the planner ran
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		if err := backlog.Split(prdID, children); err != nil {
+			return err
+		}
+		return backlog.Save(workspace.PRDPath(r.workspaceDir))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(children))
+	for i, child := range children {
+		ids[i] = child.ID
+	}
+	return ids, nil
+}
+
+// block marks a PRD blocked for reason, recording it on the last attempt
+func block(p *prd.PRD, reason string) {
+	if attempt := p.LastAttempt(); attempt != nil {
+		attempt.Blocker = reason
+	}
+	p.SetStatus(types.StatusBlocked)
+}
+
+// note records an observation on the PRD's last attempt
+func note(p *prd.PRD, observation string) {
+	if attempt := p.LastAttempt(); attempt != nil {
+		attempt.Observations = append(attempt.Observations, observation)
+	}
+}
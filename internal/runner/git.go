@@ -0,0 +1,212 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/git"
+	"github.com/daydemir/ralph/internal/llm"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/utils"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// dirtyListLimit is how many uncommitted paths the dirty-tree error names
+const dirtyListLimit = 5
+
+// rollbackPatch is the file in a run directory holding rolled-back changes
+const rollbackPatch = "rollback.patch"
+
+// prepareBranch switches to the PRD's branch, or its worktree when
+// worktrees are enabled, and checks the working tree is clean. A new branch
+// starts from the base branch rather than whatever PRD branch is checked
+// out, so PRDs don't stack on each other's unreviewed work. Ralph's own
+// state files don't count as uncommitted changes, since they are never
+// committed. Workspaces outside git are left alone.
+func (r *Runner) prepareBranch(p *prd.PRD) error {
+	if !git.IsRepo(r.workspaceDir) {
+		return nil
+	}
+	if r.cfg.Build.Worktrees {
+		if err := r.addWorktree(p.ID); err != nil {
+			return err
+		}
+	}
+
+	if !r.force {
+		dirty, err := git.Dirty(r.workDir(p.ID), workspace.RalphDir)
+		if err != nil {
+			return fmt.Errorf("failed to check working tree: %w", err)
+		}
+		if len(dirty) > 0 {
+			names := dirty
+			if len(names) > dirtyListLimit {
+				names = append(names[:dirtyListLimit:dirtyListLimit], fmt.Sprintf("and %d more", len(dirty)-dirtyListLimit))
+			}
+			return fmt.Errorf("working tree has uncommitted changes (%s); commit or stash them, or run with --force", strings.Join(names, ", "))
+		}
+	}
+
+	if r.cfg.Build.Worktrees {
+		return nil
+	}
+	branch := git.BranchName(p.ID)
+	base := r.baseBranch()
+	created, err := git.CheckoutBranch(r.workspaceDir, branch, base)
+	if err != nil {
+		return err
+	}
+	if created && base != "" {
+		r.display.Info("Branch", fmt.Sprintf("created %s from %s", branch, base))
+	} else if created {
+		r.display.Info("Branch", "created "+branch)
+	}
+	return nil
+}
+
+// baseBranch is the branch new PRD branches start from and merge back into:
+// the one checked out in the workspace, or the base of the PRD branch that
+// is checked out. Returns "" when there is none, such as on a detached HEAD.
+func (r *Runner) baseBranch() string {
+	current := git.CurrentBranch(r.workspaceDir)
+	if strings.HasPrefix(current, git.BranchPrefix) {
+		return git.BaseBranch(r.workspaceDir, current)
+	}
+	return current
+}
+
+// mergeBranches merges the branch of every completed PRD into the base it
+// was created from, dependencies first, leaving the base checked out. Split
+// PRDs are skipped, since their children's branches carry the work. On a
+// conflict the PRD's branch stays checked out for a manual merge, the
+// conflict is recorded on its attempt and its dependents wait.
+func (r *Runner) mergeBranches(backlog *prd.Backlog) {
+	if !git.IsRepo(r.workspaceDir) {
+		return
+	}
+	held := make(map[string]bool)
+	for _, p := range backlog.InDependencyOrder() {
+		if p.Status != types.StatusComplete || len(backlog.Children(p.ID)) > 0 {
+			continue
+		}
+		branch := git.BranchName(p.ID)
+		base := git.BaseBranch(r.workspaceDir, branch)
+		if base == "" || git.IsMerged(r.workspaceDir, branch, base) {
+			continue
+		}
+		if slices.ContainsFunc(p.DependsOn, func(id string) bool { return held[id] }) {
+			held[p.ID] = true
+			continue
+		}
+
+		method, err := git.MergeInto(r.workspaceDir, branch, base)
+		if err != nil {
+			held[p.ID] = true
+			var conflict *git.ConflictError
+			note := fmt.Sprintf("merge-back failed: %v", err)
+			if errors.As(err, &conflict) {
+				note = fmt.Sprintf("merge-back conflict: %v; merge %s into %s by hand", conflict, branch, base)
+			}
+			// A conflict is retried after every later merge; record it once
+			if attempt := p.LastAttempt(); attempt != nil && !lastObservation(attempt, note) {
+				attempt.Observations = append(attempt.Observations, note)
+			}
+			r.display.Warning(note)
+			continue
+		}
+		r.display.Success(fmt.Sprintf("merged %s into %s (%s)", branch, base, method))
+	}
+}
+
+// commitIteration commits everything the iteration changed and records the
+// attempt's commits. A failed commit is reported but doesn't fail the
+// iteration; the changes stay in the working tree.
+func (r *Runner) commitIteration(p *prd.PRD) {
+	attempt := p.LastAttempt()
+	if attempt == nil || !git.IsRepo(r.workspaceDir) {
+		return
+	}
+
+	// Ralph's state stays out of PRD branches, so the backlog doesn't change
+	// with the branch that is checked out; a worktree's copy is stale anyway
+	dir := r.workDir(p.ID)
+	sha, err := git.CommitAll(dir, git.CommitMessage(p, attempt), workspace.RalphDir)
+	if err != nil {
+		r.display.Warning(fmt.Sprintf("could not commit iteration: %v", err))
+	} else if sha != "" {
+		r.display.Info("Commit", fmt.Sprintf("%.7s on %s", sha, git.CurrentBranch(dir)))
+	}
+
+	if attempt.BaseCommit != "" {
+		attempt.Commits = git.CommitsSince(dir, attempt.BaseCommit)
+	}
+}
+
+// rollbackSignals are the failures whose half-finished edits shouldn't carry
+// over into the next iteration
+var rollbackSignals = map[llm.SignalType]bool{
+	llm.SignalTaskFailed:  true,
+	llm.SignalPlanFailed:  true,
+	llm.SignalBuildFailed: true,
+}
+
+// rollbackReason says why an iteration's changes should be rolled back, or
+// "" when they should be kept
+func rollbackReason(handler *llm.ConsoleHandler) string {
+	if handler.HitTokenLimit() {
+		return "token limit kill"
+	}
+	if f := handler.GetFailure(); f != nil && rollbackSignals[f.Type] {
+		return string(f.Type)
+	}
+	return ""
+}
+
+// rollback resets the working tree to the attempt's base commit, saving the
+// discarded changes as a patch in the attempt's run directory first. Ralph's
+// own state under .ralph/ is kept. When the patch can't be saved the changes
+// are left in place.
+func (r *Runner) rollback(p *prd.PRD, reason string) {
+	attempt := p.LastAttempt()
+	if attempt == nil || attempt.BaseCommit == "" || !git.IsRepo(r.workspaceDir) {
+		r.display.Warning(fmt.Sprintf("cannot roll back after %s: workspace is not a git repository", reason))
+		return
+	}
+
+	dir := r.workDir(p.ID)
+	patch, err := git.DiffSince(dir, attempt.BaseCommit, workspace.RalphDir)
+	if err != nil {
+		r.display.Warning(fmt.Sprintf("rollback failed: %v", err))
+		return
+	}
+	if patch == "" {
+		return
+	}
+
+	runDir := workspace.RunDir(r.workspaceDir, p.ID, attempt.Iteration)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		r.display.Warning(fmt.Sprintf("failed to create %s, not rolling back: %v", runDir, err))
+		return
+	}
+	path := filepath.Join(runDir, rollbackPatch)
+	if err := utils.WriteFileAtomic(path, []byte(patch), 0644); err != nil {
+		r.display.Warning(fmt.Sprintf("failed to save rolled-back changes, not rolling back: %v", err))
+		return
+	}
+
+	if err := git.ResetTo(dir, attempt.BaseCommit, workspace.RalphDir); err != nil {
+		r.display.Warning(fmt.Sprintf("rollback failed: %v", err))
+		return
+	}
+
+	rel := r.relPath(path)
+	attempt.Patch = rel
+	attempt.Observations = append(attempt.Observations,
+		fmt.Sprintf("rolled back to %.7s after %s; changes saved in %s", attempt.BaseCommit, reason, rel))
+	r.display.Warning(fmt.Sprintf("rolled back to %.7s after %s, changes saved in %s", attempt.BaseCommit, reason, rel))
+}
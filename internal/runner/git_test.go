@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/daydemir/ralph/internal/git"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestSerialBranchesMergeBack(t *testing.T) {
+	a, b := testPRD("First"), testPRD("Second")
+	backlog := &prd.Backlog{Features: []*prd.PRD{a, b}}
+	dir := newTestWorkspace(t, backlog)
+	r := newTestRunner(dir, nil)
+
+	// Each PRD branches from main, even while the other's branch is checked out
+	for _, p := range []*prd.PRD{a, b} {
+		if err := r.prepareBranch(p); err != nil {
+			t.Fatalf("prepareBranch(%s) error = %v", p.ID, err)
+		}
+		writeFile(t, filepath.Join(dir, p.ID+".go"), "package main\n")
+		mustGit(t, dir, "add", p.ID+".go")
+		mustGit(t, dir, "commit", "--quiet", "-m", "feat: "+p.ID)
+	}
+	for _, p := range []*prd.PRD{a, b} {
+		if got := git.BaseBranch(dir, git.BranchName(p.ID)); got != "main" {
+			t.Errorf("BaseBranch(%s) = %q, want main", p.ID, got)
+		}
+	}
+	if git.IsMerged(dir, git.BranchName(a.ID), git.BranchName(b.ID)) {
+		t.Errorf("%s is stacked on %s", git.BranchName(b.ID), git.BranchName(a.ID))
+	}
+
+	// Only completed PRDs are merged back, leaving main checked out
+	a.SetStatus(types.StatusComplete)
+	r.mergeCompleted(backlog)
+	if got := git.CurrentBranch(dir); got != "main" {
+		t.Errorf("CurrentBranch() = %q, want main", got)
+	}
+	if !git.IsMerged(dir, git.BranchName(a.ID), "main") {
+		t.Errorf("completed %s not merged into main", git.BranchName(a.ID))
+	}
+	if git.IsMerged(dir, git.BranchName(b.ID), "main") {
+		t.Errorf("pending %s merged into main", git.BranchName(b.ID))
+	}
+
+	b.SetStatus(types.StatusComplete)
+	r.mergeCompleted(backlog)
+	if !git.IsMerged(dir, git.BranchName(b.ID), "main") {
+		t.Errorf("completed %s not merged into main", git.BranchName(b.ID))
+	}
+	if got := git.ChangedFiles(dir, git.Head(dir)+"~2"); len(got) != 2 {
+		t.Errorf("main changed %v, want both PRDs' files", got)
+	}
+}
@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/git"
+	"github.com/daydemir/ralph/internal/llm"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+// workerResult is what a parallel worker reports when its iteration ends
+type workerResult struct {
+	prdID  string
+	result *IterationResult
+	err    error
+}
+
+// RunParallel runs up to max iterations with up to workers PRDs executing at
+// once, each in its own worktree. Only PRDs whose dependencies are complete
+// and merged are started. A PRD that ends with a hard failure isn't started
+// again, but the other workers carry on; an error stops new work and waits
+// for the running workers.
+func (r *Runner) RunParallel(ctx context.Context, workers, max int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if !git.IsRepo(r.workspaceDir) {
+		return fmt.Errorf("parallel runs need a git repository for their worktrees")
+	}
+	r.cfg.Build.Worktrees = true
+	r.display.LoopHeader()
+
+	if err := r.ReviewPending(ctx); err != nil {
+		return err
+	}
+
+	results := make(chan workerResult)
+	running := map[string]bool{}
+	failed := map[string]error{}
+	started := 0
+	var stopErr error
+
+	for {
+		for stopErr == nil && len(running) < workers && started < max && ctx.Err() == nil && !r.Stopped() {
+			next, backlog, err := r.nextParallel(ctx, running, failed)
+			if err != nil {
+				stopErr = err
+				break
+			}
+			if next == nil {
+				break
+			}
+
+			started++
+			running[next.ID] = true
+			r.display.Iteration(started, max, next.ID, backlog.CountByStatus(types.StatusComplete), len(backlog.Features))
+			go r.worker(ctx, next.ID, results)
+		}
+		if len(running) == 0 {
+			break
+		}
+
+		done := <-results
+		delete(running, done.prdID)
+		switch {
+		case done.err != nil:
+			failed[done.prdID] = done.err
+			if stopErr == nil {
+				stopErr = fmt.Errorf("%s: %w", done.prdID, done.err)
+			}
+		case done.result.Failure != nil && done.result.Failure.Type != llm.SignalBlocked:
+			failed[done.prdID] = fmt.Errorf("%s: %s", done.result.Failure.Type, done.result.Failure.Detail)
+		}
+	}
+
+	if errors.Is(stopErr, ErrHumanReview) {
+		return nil
+	}
+	if r.Stopped() && (stopErr == nil || errors.Is(stopErr, context.Canceled)) {
+		r.display.Warning("stopped; run 'ralph run --parallel' to continue")
+		return nil
+	}
+	if stopErr == nil {
+		stopErr = ctx.Err()
+	}
+	if stopErr != nil {
+		r.display.Error("parallel run stopped: " + stopErr.Error())
+		return stopErr
+	}
+	if len(failed) > 0 {
+		return failedError(failed)
+	}
+	if started < max {
+		r.display.AllComplete()
+	} else {
+		r.display.MaxIterations(max)
+	}
+	return nil
+}
+
+// worker runs one iteration of a PRD with its output prefixed by the PRD ID
+func (r *Runner) worker(ctx context.Context, prdID string, results chan<- workerResult) {
+	w := *r
+	w.display = r.display.WithPrefix("[" + prdID + "]")
+
+	result, err := w.runAndReview(ctx, prdID)
+	if err != nil {
+		w.display.Error(fmt.Sprintf("%s failed: %v", prdID, err))
+	} else if result.Failure != nil && result.Failure.Type != llm.SignalBlocked {
+		w.display.Error(fmt.Sprintf("%s failed: %s: %s", prdID, result.Failure.Type, result.Failure.Detail))
+	}
+	results <- workerResult{prdID: prdID, result: result, err: err}
+}
+
+// nextParallel re-checks blocked PRDs, reviews the ones that went to review,
+// escalates PRDs that are out of iterations and returns the first runnable
+// PRD that isn't running, hasn't failed and whose dependencies are merged.
+// Returns nil when there is none. Checks and escalations take the state
+// lock only to apply their results, so running workers aren't held up by
+// their commands and planner sessions.
+func (r *Runner) nextParallel(ctx context.Context, running map[string]bool, failed map[string]error) (*prd.PRD, *prd.Backlog, error) {
+	if err := r.CheckBlocked(ctx); err != nil {
+		return nil, nil, err
+	}
+	// Running workers review their own PRDs
+	if err := r.reviewPending(ctx, running); err != nil {
+		return nil, nil, err
+	}
+	for {
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return nil, nil, err
+		}
+		var next *prd.PRD
+		for _, p := range backlog.Runnable() {
+			if !running[p.ID] && failed[p.ID] == nil && r.dependenciesMerged(p) {
+				next = p
+				break
+			}
+		}
+		if next == nil || !next.IterationsExhausted() {
+			return next, backlog, nil
+		}
+		if err := r.Escalate(ctx, next.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// failedError lists the PRDs that failed during a parallel run
+func failedError(failed map[string]error) error {
+	ids := make([]string, 0, len(failed))
+	for id := range failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	lines := make([]string, len(ids))
+	for i, id := range ids {
+		lines[i] = fmt.Sprintf("%s: %v", id, failed[id])
+	}
+	return fmt.Errorf("%d PRD(s) failed:\n  %s", len(ids), strings.Join(lines, "\n  "))
+}
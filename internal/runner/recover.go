@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/daydemir/ralph/internal/git"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// Interruption is an iteration a killed Ralph process left unfinished,
+// reconstructed from the PRD's run directory and git state
+type Interruption struct {
+	PRDID     string
+	Title     string
+	Iteration int
+	StartedAt time.Time
+	Branch    string   // branch checked out where the PRD runs, "" outside git
+	Commits   []string // commits made since the attempt started
+	Changed   []string // uncommitted changes left in the working tree
+	Artifacts []string // files and directories in the attempt's run directory
+}
+
+// Summary describes what the interrupted iteration got done
+func (i Interruption) Summary() string {
+	parts := []string{fmt.Sprintf("iteration %d started %s", i.Iteration, i.StartedAt.Format(time.DateTime))}
+	if i.Branch != "" {
+		parts = append(parts, fmt.Sprintf("%d commit(s) and %d uncommitted change(s) on %s", len(i.Commits), len(i.Changed), i.Branch))
+	}
+	if len(i.Artifacts) > 0 {
+		parts = append(parts, "run directory has "+strings.Join(i.Artifacts, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Interrupted finds PRDs left in progress by a Ralph process that was
+// killed mid-iteration
+func (r *Runner) Interrupted() ([]Interruption, error) {
+	backlog, err := r.loadBacklog()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Interruption
+	for _, p := range backlog.Interrupted() {
+		found = append(found, r.forPRD(p.ID).interruption(p))
+	}
+	return found, nil
+}
+
+func (r *Runner) interruption(p *prd.PRD) Interruption {
+	attempt := p.LastAttempt()
+	i := Interruption{
+		PRDID:     p.ID,
+		Title:     p.Title,
+		Iteration: attempt.Iteration,
+		StartedAt: attempt.StartedAt,
+	}
+
+	if entries, err := os.ReadDir(workspace.RunDir(r.workspaceDir, p.ID, attempt.Iteration)); err == nil {
+		for _, e := range entries {
+			i.Artifacts = append(i.Artifacts, e.Name())
+		}
+	}
+
+	dir := r.workDir(p.ID)
+	if !git.IsRepo(dir) {
+		return i
+	}
+	i.Branch = git.CurrentBranch(dir)
+	if attempt.BaseCommit != "" {
+		i.Commits = git.CommitsSince(dir, attempt.BaseCommit)
+	}
+	i.Changed, _ = git.Dirty(dir, workspace.RalphDir)
+	return i
+}
+
+// Recover closes an interrupted iteration and applies action to its PRD.
+// Resume and block commit the leftover changes on the PRD's branch; reset
+// saves them as a patch and returns the PRD to pending. The changes are
+// looked for where the iteration ran, whatever the current flags say.
+func (r *Runner) Recover(prdID string, action types.InterruptAction) error {
+	w := r.forPRD(prdID)
+	return w.withState(func() error {
+		backlog, err := w.loadBacklog()
+		if err != nil {
+			return err
+		}
+		p := backlog.Find(prdID)
+		if p == nil {
+			return fmt.Errorf("PRD %s not found in backlog", prdID)
+		}
+		attempt := p.LastAttempt()
+		if attempt == nil || !attempt.Unfinished() {
+			return fmt.Errorf("PRD %s has no interrupted iteration", prdID)
+		}
+
+		interruption := w.interruption(p)
+		attempt.EndedAt = time.Now()
+		attempt.Outcome = types.OutcomeInterrupted
+		attempt.Commits = interruption.Commits
+		attempt.Recovery = action
+		note(p, "interrupted: "+interruption.Summary())
+
+		switch action {
+		case types.InterruptReset:
+			if w.onBranch(p, interruption) && len(interruption.Commits)+len(interruption.Changed) > 0 {
+				w.rollback(p, "interrupted iteration")
+			}
+			p.SetStatus(types.StatusPending)
+			w.display.Info("Recovered", fmt.Sprintf("%s reset to pending", p.ID))
+		case types.InterruptBlock:
+			w.commitInterrupted(p, interruption)
+			block(p, "interrupted during iteration "+fmt.Sprint(attempt.Iteration))
+			w.display.Info("Recovered", fmt.Sprintf("%s blocked; run 'ralph unblock %s' to continue it", p.ID, p.ID))
+		default:
+			w.commitInterrupted(p, interruption)
+			// Losing the process shouldn't cost an iteration of the budget
+			if p.MaxIterations <= 0 {
+				p.MaxIterations = prd.DefaultMaxIterations
+			}
+			p.MaxIterations++
+			w.display.Info("Recovered", fmt.Sprintf("%s resumes from its leftover changes", p.ID))
+		}
+
+		if err := w.recordEntry(p); err != nil {
+			return err
+		}
+		return backlog.Save(workspace.PRDPath(w.workspaceDir))
+	})
+}
+
+// commitInterrupted commits an interrupted iteration's leftover changes
+func (r *Runner) commitInterrupted(p *prd.PRD, i Interruption) {
+	if len(i.Changed) > 0 && r.onBranch(p, i) {
+		r.commitIteration(p)
+	}
+}
+
+// onBranch reports whether the PRD's working tree is still on its branch.
+// A tree someone has since switched to another branch is left alone.
+func (r *Runner) onBranch(p *prd.PRD, i Interruption) bool {
+	if i.Branch == "" {
+		return false
+	}
+	if i.Branch != git.BranchName(p.ID) {
+		r.display.Warning(fmt.Sprintf("%s is on %s, not %s; leaving its changes alone",
+			r.relPath(r.workDir(p.ID)), i.Branch, git.BranchName(p.ID)))
+		return false
+	}
+	return true
+}
+
+// forPRD returns a runner working where the PRD's iteration ran: its
+// worktree when one exists, even if this run wasn't started with worktrees
+func (r *Runner) forPRD(prdID string) *Runner {
+	if r.cfg.Build.Worktrees || !r.hasWorktree(prdID) {
+		return r
+	}
+	cfg := *r.cfg
+	cfg.Build.Worktrees = true
+	w := *r
+	w.cfg = &cfg
+	return &w
+}
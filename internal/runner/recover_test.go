@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/git"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+func TestRecoverUsesWorktreeWithoutFlag(t *testing.T) {
+	p := testPRD("Feature")
+	dir := newTestWorkspace(t, &prd.Backlog{Features: []*prd.PRD{p}})
+	mainHead := git.Head(dir)
+
+	// An earlier --worktree run was killed mid-iteration with changes left over
+	parallel := newTestRunner(dir, func(cfg *config.Config) { cfg.Build.Worktrees = true })
+	if err := parallel.addWorktree(p.ID); err != nil {
+		t.Fatal(err)
+	}
+	worktree := workspace.WorktreePath(dir, p.ID)
+	p.StartAttempt(git.Head(worktree))
+	if err := (&prd.Backlog{Features: []*prd.PRD{p}}).Save(workspace.PRDPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(worktree, "feature.go"), "package main\n")
+
+	r := newTestRunner(dir, nil)
+	interrupted, err := r.Interrupted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(interrupted) != 1 || interrupted[0].Branch != git.BranchName(p.ID) || !reflect.DeepEqual(interrupted[0].Changed, []string{"feature.go"}) {
+		t.Fatalf("Interrupted() = %+v, want feature.go changed on %s", interrupted, git.BranchName(p.ID))
+	}
+
+	if err := r.Recover(p.ID, types.InterruptResume); err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if got := git.CommitsSince(worktree, mainHead); len(got) != 1 {
+		t.Errorf("worktree branch has commits %v, want the leftover changes committed", got)
+	}
+	if git.Head(dir) != mainHead || git.CurrentBranch(dir) != "main" {
+		t.Errorf("main checkout moved to %s on %s", git.Head(dir), git.CurrentBranch(dir))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature.go")); !os.IsNotExist(err) {
+		t.Errorf("feature.go leaked into the main checkout: %v", err)
+	}
+	if a := loadPRD(t, dir, p.ID).LastAttempt(); a.Unfinished() || len(a.Commits) != 1 {
+		t.Errorf("attempt = %+v, want it closed with the recovery commit", a)
+	}
+}
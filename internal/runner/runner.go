@@ -0,0 +1,890 @@
+// Package runner drives PRD execution: it selects the next PRD from the
+// backlog, runs one executor iteration with a fresh Claude context, records
+// the attempt, and sends completion claims through review.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/git"
+	"github.com/daydemir/ralph/internal/llm"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/proc"
+	"github.com/daydemir/ralph/internal/prompts"
+	"github.com/daydemir/ralph/internal/repair"
+	"github.com/daydemir/ralph/internal/review"
+	"github.com/daydemir/ralph/internal/schema"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/verify"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// ErrNothingToRun is returned when no PRD in the backlog is runnable
+var ErrNothingToRun = errors.New("no runnable PRDs in backlog")
+
+// Runner executes PRDs from a workspace backlog
+type Runner struct {
+	workspaceDir string
+	cfg          *config.Config
+	claude       *llm.Claude
+	display      *display.Display
+	model        string
+	force        bool        // run on a dirty working tree
+	state        *sync.Mutex // serializes backlog and progress updates across workers
+	stop         *stopSignal
+}
+
+// stopSignal is closed once when the runner is asked to stop
+type stopSignal struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// IterationResult summarizes a single executor iteration
+type IterationResult struct {
+	PRDID    string
+	Outcome  types.Outcome
+	Status   types.Status
+	Failure  *llm.FailureSignal
+	Tokens   llm.TokenStats
+	Duration time.Duration
+}
+
+// New creates a runner for the given workspace. An empty model falls back to the config.
+func New(workspaceDir string, cfg *config.Config, d *display.Display, model string) *Runner {
+	if model == "" {
+		model = cfg.LLM.Model
+	}
+	return &Runner{
+		workspaceDir: workspaceDir,
+		cfg:          cfg,
+		claude:       llm.NewClaude(cfg.Claude.Binary),
+		display:      d,
+		model:        model,
+		state:        &sync.Mutex{},
+		stop:         &stopSignal{ch: make(chan struct{})},
+	}
+}
+
+// Stop asks running iterations to end once Claude's current tool call
+// finishes and keeps new iterations and reviews from starting. The stopped
+// iterations are recorded as usual. Safe to call from another goroutine.
+func (r *Runner) Stop() {
+	r.stop.once.Do(func() { close(r.stop.ch) })
+}
+
+// Stopped reports whether Stop was called
+func (r *Runner) Stopped() bool {
+	select {
+	case <-r.stop.ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetForce lets iterations start when the working tree has uncommitted changes
+func (r *Runner) SetForce(force bool) {
+	r.force = force
+}
+
+// Verifier returns a verification runner for a PRD that writes evidence to
+// evidenceDir and records the flakes it finds for SaveFlaky
+func (r *Runner) Verifier(prdID, evidenceDir string) (*verify.Runner, error) {
+	reruns := r.cfg.Verify.FlakyReruns
+	if reruns < 0 {
+		reruns = 0
+	}
+
+	return &verify.Runner{
+		WorkDir:     r.workDir(prdID),
+		Timeout:     r.cfg.Verify.Timeout,
+		EvidenceDir: evidenceDir,
+		Reruns:      reruns,
+		Flaky:       &verify.FlakyRegistry{},
+	}, nil
+}
+
+// SaveFlaky merges the flakes recorded by a verifier created with Verifier
+// into the workspace registry, dropping expired entries. The registry is
+// reloaded under the state lock so concurrent workers don't lose each
+// other's observations.
+func (r *Runner) SaveFlaky(v *verify.Runner) error {
+	return r.withState(func() error {
+		path := workspace.FlakyPath(r.workspaceDir)
+		registry, err := verify.LoadFlakyRegistry(path)
+		if err != nil {
+			return err
+		}
+		registry.Merge(v.Flaky)
+		registry.Prune(time.Now())
+		return registry.Save(path)
+	})
+}
+
+func (r *Runner) reviewer(prdID string) (*review.Reviewer, error) {
+	verifier, err := r.Verifier(prdID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &review.Reviewer{
+		WorkspaceDir: r.workspaceDir,
+		WorkDir:      r.workDir(prdID),
+		Claude:       r.claude,
+		Model:        r.model,
+		AllowedTools: r.cfg.Claude.AllowedTools,
+		Verifier:     *verifier,
+		Display:      r.display,
+	}, nil
+}
+
+// Loop runs up to max iterations, stopping early when the backlog has nothing
+// runnable, a PRD needs human review, or an iteration ends with a hard failure
+func (r *Runner) Loop(ctx context.Context, max int) error {
+	r.display.LoopHeader()
+
+	for i := 1; i <= max; i++ {
+		if r.Stopped() {
+			r.display.Warning("stopped; run 'ralph run' to continue")
+			return nil
+		}
+		next, backlog, err := r.selectNext(ctx)
+		if errors.Is(err, ErrHumanReview) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			r.display.AllComplete()
+			return nil
+		}
+
+		completed := backlog.CountByStatus(types.StatusComplete)
+		r.display.Iteration(i, max, next.ID, completed, len(backlog.Features))
+
+		result, err := r.RunIteration(ctx, next.ID)
+		if err != nil {
+			r.display.LoopFailed(next.ID, err, completed)
+			return err
+		}
+		if result.Failure != nil && result.Failure.Type != llm.SignalBlocked {
+			err := fmt.Errorf("%s: %s", result.Failure.Type, result.Failure.Detail)
+			r.display.LoopFailed(next.ID, err, completed)
+			return err
+		}
+	}
+
+	if err := r.ReviewPending(ctx); err != nil {
+		return err
+	}
+	r.display.MaxIterations(max)
+	return nil
+}
+
+// RunNext reviews any pending claims, re-checks blocked PRDs and escalates
+// PRDs out of iterations, then runs one iteration of the next runnable PRD
+func (r *Runner) RunNext(ctx context.Context) (*IterationResult, error) {
+	next, _, err := r.selectNext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		return nil, ErrNothingToRun
+	}
+
+	return r.runAndReview(ctx, next.ID)
+}
+
+// runAndReview runs one iteration of a PRD and reviews its completion claim
+func (r *Runner) runAndReview(ctx context.Context, prdID string) (*IterationResult, error) {
+	result, err := r.RunIteration(ctx, prdID)
+	if err != nil {
+		return nil, err
+	}
+	// A stopped run leaves the claim for the next run to review
+	if result.Status != types.StatusPendingReview || r.Stopped() {
+		return result, nil
+	}
+
+	if err := r.ReviewPRD(ctx, result.PRDID); err != nil {
+		return nil, err
+	}
+	if backlog, err := r.loadBacklog(); err == nil {
+		if p := backlog.Find(result.PRDID); p != nil {
+			result.Status = p.Status
+		}
+	}
+	return result, nil
+}
+
+// RunIteration executes one iteration of the given PRD and records the attempt
+func (r *Runner) RunIteration(ctx context.Context, prdID string) (*IterationResult, error) {
+	backlogPath := workspace.PRDPath(r.workspaceDir)
+
+	var (
+		p             *prd.PRD
+		learnings     []prd.Learning
+		codebaseState string
+		state         []repair.File
+		started       bool
+	)
+	err := r.withState(func() error {
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		p = backlog.Find(prdID)
+		if p == nil {
+			return fmt.Errorf("PRD %s not found in backlog", prdID)
+		}
+
+		if err := r.prepareBranch(p); err != nil {
+			return err
+		}
+		p.StartAttempt(git.Head(r.workDir(p.ID)))
+		if err := backlog.Save(backlogPath); err != nil {
+			return err
+		}
+		started = true
+		learnings, codebaseState, err = r.progressContext(p)
+		if err != nil {
+			return err
+		}
+		state, err = r.snapshotState(p.ID)
+		return err
+	})
+	if err != nil {
+		if started {
+			return nil, r.failAttempt(prdID, err)
+		}
+		return nil, err
+	}
+
+	baseline, err := r.snapshotBaseline(ctx, p)
+	if err != nil {
+		return nil, r.failAttempt(prdID, err)
+	}
+
+	flaky, err := verify.LoadFlakyRegistry(workspace.FlakyPath(r.workspaceDir))
+	if err != nil {
+		return nil, r.failAttempt(prdID, err)
+	}
+	flaky.Prune(time.Now())
+
+	prompt, err := r.buildPrompt(p, baseline, flaky, learnings, codebaseState)
+	if err != nil {
+		return nil, r.failAttempt(prdID, err)
+	}
+
+	start := time.Now()
+	model := r.model
+	if p.Model != "" {
+		model = p.Model
+	}
+	handler, err := r.executeIn(ctx, r.workDir(p.ID), prompt, model, r.cfg.Claude.AllowedTools)
+	if err != nil {
+		return nil, r.failAttempt(prdID, err)
+	}
+
+	result := &IterationResult{
+		PRDID:    prdID,
+		Tokens:   handler.GetTokenStats(),
+		Duration: time.Since(start),
+	}
+	// Repair sessions and git work can take a while, so they run without the
+	// state lock; it is only held to reload the backlog and record results
+	if err := r.repairState(ctx, state); err != nil {
+		return nil, err
+	}
+
+	err = r.withState(func() error {
+		// The executor edits prd.json during the iteration, so reload before recording
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		p = backlog.Find(prdID)
+		if p == nil {
+			return fmt.Errorf("PRD %s was removed from the backlog during execution", prdID)
+		}
+
+		recordAttempt(p, handler, result)
+		if err := r.recordLearnings(p, strings.Join(handler.GetCapturedOutput(), "\n")); err != nil {
+			return err
+		}
+		return backlog.Save(backlogPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cfg.Build.Rollback == types.RollbackReset {
+		if reason := rollbackReason(handler); reason != "" {
+			r.rollback(p, reason)
+		}
+	}
+	r.commitIteration(p)
+
+	err = r.withState(func() error {
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		current := backlog.Find(prdID)
+		if current == nil {
+			return fmt.Errorf("PRD %s was removed from the backlog during execution", prdID)
+		}
+		// Rollback and commit only touch this iteration's attempt
+		if attempt, now := p.LastAttempt(), current.LastAttempt(); attempt != nil && now != nil && now.Iteration == attempt.Iteration {
+			*now = *attempt
+		}
+		p = current
+
+		// Completion claims are recorded once review decides the outcome
+		if result.Outcome != types.OutcomeComplete {
+			if err := r.recordEntry(p); err != nil {
+				return err
+			}
+		}
+		return backlog.Save(backlogPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.display.Tokens(result.Tokens.TotalTokens, result.Tokens.InputTokens, result.Tokens.OutputTokens)
+	r.display.Duration(result.Duration)
+
+	if limit := r.cfg.Build.SplitAfterBailouts; limit > 0 && p.ConsecutiveBailouts() >= limit {
+		r.display.Warning(fmt.Sprintf("%s bailed out %d times in a row, splitting it", p.ID, p.ConsecutiveBailouts()))
+		children, err := r.Split(ctx, p.ID)
+		if err != nil {
+			r.display.Warning(fmt.Sprintf("split failed: %v", err))
+		} else {
+			r.display.Success(fmt.Sprintf("%s split into %s", p.ID, strings.Join(children, ", ")))
+			result.Status = types.StatusBlocked
+		}
+	}
+	return result, nil
+}
+
+// failAttempt closes the PRD's open attempt as failed when the iteration
+// couldn't run, so the next run doesn't take it for one a killed process
+// left unfinished. Returns cause, along with any error saving the backlog.
+func (r *Runner) failAttempt(prdID string, cause error) error {
+	err := r.withState(func() error {
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		p := backlog.Find(prdID)
+		if p == nil {
+			return nil
+		}
+		attempt := p.LastAttempt()
+		if attempt == nil || !attempt.Unfinished() {
+			return nil
+		}
+		attempt.EndedAt = time.Now()
+		attempt.Outcome = types.OutcomeFailed
+		attempt.Observations = append(attempt.Observations, "iteration could not run: "+cause.Error())
+		return backlog.Save(workspace.PRDPath(r.workspaceDir))
+	})
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+// ReviewPending runs the review stage for every PRD awaiting review
+func (r *Runner) ReviewPending(ctx context.Context) error {
+	return r.reviewPending(ctx, nil)
+}
+
+// reviewPending reviews every PRD awaiting review except those in skip
+func (r *Runner) reviewPending(ctx context.Context, skip map[string]bool) error {
+	backlog, err := r.loadBacklog()
+	if err != nil {
+		return err
+	}
+
+	for _, pending := range backlog.WithStatus(types.StatusPendingReview) {
+		if r.Stopped() {
+			return nil
+		}
+		if skip[pending.ID] {
+			continue
+		}
+		if err := r.ReviewPRD(ctx, pending.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReviewPRD verifies a single PRD's completion claim and transitions it to
+// complete when both verification and the reviewer pass, or back to
+// in_progress with the reviewer's findings otherwise
+func (r *Runner) ReviewPRD(ctx context.Context, prdID string) error {
+	backlog, err := r.loadBacklog()
+	if err != nil {
+		return err
+	}
+	p := backlog.Find(prdID)
+	if p == nil {
+		return fmt.Errorf("PRD %s not found in backlog", prdID)
+	}
+	if p.Status != types.StatusPendingReview {
+		return fmt.Errorf("PRD %s is %s, not %s", prdID, p.Status, types.StatusPendingReview)
+	}
+
+	reviewer, err := r.reviewer(prdID)
+	if err != nil {
+		return err
+	}
+	result, report, err := reviewer.Review(ctx, p)
+	if err != nil {
+		return fmt.Errorf("review of %s failed: %w", prdID, err)
+	}
+	if err := r.SaveFlaky(&reviewer.Verifier); err != nil {
+		return err
+	}
+
+	return r.withState(func() error {
+		// Reload in case the reviewer session touched the backlog
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		p := backlog.Find(prdID)
+		if p == nil {
+			return fmt.Errorf("PRD %s was removed from the backlog during review", prdID)
+		}
+
+		if attempt := p.LastAttempt(); attempt != nil {
+			attempt.Review = result
+			attempt.EvidencePath = report.Path
+		}
+
+		if result.Passed {
+			p.SetStatus(types.StatusComplete)
+			r.display.Success(fmt.Sprintf("%s complete: %s", p.ID, result.Summary))
+			r.mergeCompleted(backlog)
+		} else {
+			if attempt := p.LastAttempt(); attempt != nil {
+				attempt.Observations = append(attempt.Observations, "review rejected completion: "+result.Summary)
+			}
+			p.SetStatus(types.StatusInProgress)
+			r.display.Warning(fmt.Sprintf("%s failed review: %s", p.ID, result.Summary))
+		}
+
+		if err := r.recordEntry(p); err != nil {
+			return err
+		}
+		return backlog.Save(workspace.PRDPath(r.workspaceDir))
+	})
+}
+
+// recordEntry appends the progress entry for a PRD's last attempt once its
+// outcome is final
+func (r *Runner) recordEntry(p *prd.PRD) error {
+	attempt := p.LastAttempt()
+	if attempt == nil {
+		return nil
+	}
+
+	path := workspace.ProgressPath(r.workspaceDir)
+	progress, err := prd.LoadOrNewProgress(path)
+	if err != nil {
+		return err
+	}
+
+	entry := prd.EntryFromAttempt(p, attempt)
+	if attempt.BaseCommit != "" {
+		entry.FilesModified = git.ChangedFiles(r.workDir(p.ID), attempt.BaseCommit)
+		entry.GitCommits = git.CommitsSince(r.workDir(p.ID), attempt.BaseCommit)
+	}
+
+	if err := progress.AppendEntry(entry); err != nil {
+		if errors.Is(err, prd.ErrEntryExists) {
+			r.display.Warning(err.Error())
+			return nil
+		}
+		return err
+	}
+	return progress.Save(path)
+}
+
+func (r *Runner) buildPrompt(p *prd.PRD, baseline *verify.Report, flaky *verify.FlakyRegistry, learnings []prd.Learning, codebaseState string) (string, error) {
+	base, err := prompts.GetForWorkspace(r.workspaceDir, "build.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to load build prompt: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base)
+	sb.WriteString("\n\n<assignment>\n")
+	sb.WriteString(fmt.Sprintf("Assigned PRD: %s (iteration %d of %d)\n", p.ID, p.CurrentIteration, p.MaxIterations))
+	if prev := previousReview(p); prev != nil && !prev.Passed {
+		sb.WriteString("\nYour previous completion claim was rejected by review:\n")
+		sb.WriteString(prev.Summary + "\n")
+		sb.WriteString("Address these findings before claiming completion again.\n")
+	}
+	if baseline != nil && !baseline.Passed {
+		sb.WriteString("\nThese verification commands were already failing before this iteration started.\n")
+		sb.WriteString("Only new failures count against this PRD:\n")
+		for _, f := range baseline.Failures() {
+			sb.WriteString(fmt.Sprintf("- [%s] %s\n", f.Kind, f.Command))
+		}
+	}
+	if prev := previousAttempt(p); prev != nil && prev.Patch != "" {
+		sb.WriteString("\nYour previous attempt failed and its changes were rolled back, so you are\n")
+		sb.WriteString("starting from a clean tree. The discarded diff is saved in " + prev.Patch + "\n")
+		sb.WriteString("in case parts of it are worth reusing.\n")
+	}
+	if dir := r.workDir(p.ID); dir != r.workspaceDir {
+		sb.WriteString("\nYou are working in an isolated git worktree at " + dir + ".\n")
+		sb.WriteString("Make all code changes there. Ralph's context files (prd.json, codebase-map.md,\n")
+		sb.WriteString("fix_plan.md) are in " + workspace.Path(r.workspaceDir) + ", not in the worktree's .ralph/.\n")
+	}
+	sb.WriteString("</assignment>\n")
+
+	if len(flaky.Tests) > 0 {
+		sb.WriteString("\n<known-flaky-tests>\n")
+		sb.WriteString("These tests have failed intermittently in recent runs. Ralph reruns failing\n")
+		sb.WriteString("commands and only ignores a failure that passes on rerun, so a failure that\n")
+		sb.WriteString("persists is real. Don't try to fix their flakiness unless this PRD is about it.\n")
+		for _, t := range flaky.Tests {
+			sb.WriteString(fmt.Sprintf("- %s (flaked %d times)\n", t.Name, t.Count))
+		}
+		sb.WriteString("</known-flaky-tests>\n")
+	}
+
+	if codebaseState != "" {
+		sb.WriteString("\n<codebase-state>\n" + codebaseState + "\n</codebase-state>\n")
+	}
+
+	if len(learnings) > 0 {
+		sb.WriteString("\n<learnings>\n")
+		for _, l := range learnings {
+			line := "- " + l.Content
+			if l.Type != "" {
+				line = fmt.Sprintf("- [%s] %s", l.Type, l.Content)
+			}
+			if l.Context != "" {
+				line += " (" + l.Context + ")"
+			}
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("</learnings>\n")
+	}
+	return sb.String(), nil
+}
+
+// progressContext returns the summarized codebase state and the learnings
+// most relevant to p for the build prompt, counting them as referenced
+func (r *Runner) progressContext(p *prd.PRD) ([]prd.Learning, string, error) {
+	path := workspace.ProgressPath(r.workspaceDir)
+	progress, err := prd.LoadOrNewProgress(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit := r.cfg.Build.LearningsLimit
+	if limit < 0 {
+		return nil, progress.CodebaseState, nil
+	}
+	learnings := progress.RelevantLearnings(p, limit, r.cfg.Build.LearningsBudget)
+	if len(learnings) == 0 {
+		return nil, progress.CodebaseState, nil
+	}
+
+	var ids []string
+	for _, l := range learnings {
+		ids = append(ids, l.ID)
+	}
+	progress.MarkReferenced(ids)
+	if err := progress.Save(path); err != nil {
+		return nil, "", err
+	}
+	return learnings, progress.CodebaseState, nil
+}
+
+var learningPattern = regexp.MustCompile(`###LEARNING:([a-z-]*):([^#]+)###`)
+
+// recordLearnings stores learnings the executor signaled, scoped to the
+// PRD's related files and tags. Near-duplicates of known learnings are merged.
+func (r *Runner) recordLearnings(p *prd.PRD, output string) error {
+	matches := learningPattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	path := workspace.ProgressPath(r.workspaceDir)
+	progress, err := prd.LoadOrNewProgress(path)
+	if err != nil {
+		return err
+	}
+
+	added := 0
+	for _, match := range matches {
+		learningType := types.LearningType(match[1])
+		if !learningType.IsValid() {
+			learningType = ""
+		}
+		_, isNew := progress.AddLearning(prd.Learning{
+			Type:          learningType,
+			Content:       strings.TrimSpace(match[2]),
+			SourcePRDID:   p.ID,
+			SourceEntryID: prd.EntryID(p.ID, p.CurrentIteration),
+			AppliesTo:     append(append([]string{}, p.RelatedFiles...), p.Tags...),
+		})
+		if isNew {
+			added++
+		}
+	}
+
+	if added < len(matches) {
+		r.display.Info("Learnings", fmt.Sprintf("%d new, %d merged into existing", added, len(matches)-added))
+	}
+	return progress.Save(path)
+}
+
+// snapshotState captures the state files the executor may edit, so they
+// can be validated against and restored after the iteration. Restoring a
+// file only undoes prdID's changes, keeping what other workers saved.
+// Restores take the state lock, so repairState must run without it.
+func (r *Runner) snapshotState(prdID string) ([]repair.File, error) {
+	backlogPath := workspace.PRDPath(r.workspaceDir)
+	progressPath := workspace.ProgressPath(r.workspaceDir)
+
+	backlogBefore, err := repair.Snapshot(backlogPath)
+	if err != nil {
+		return nil, err
+	}
+	progressBefore, err := repair.Snapshot(progressPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return []repair.File{
+		{
+			Path:     backlogPath,
+			Before:   backlogBefore,
+			Validate: func() *types.ValidationErrors { return prd.ValidateBacklogFile(backlogPath, backlogBefore) },
+			Schema:   schemaJSON("prd"),
+			Restore: func() error {
+				return r.withState(func() error { return prd.RestorePRD(backlogPath, backlogBefore, prdID) })
+			},
+		},
+		{
+			Path:     progressPath,
+			Before:   progressBefore,
+			Validate: func() *types.ValidationErrors { return prd.ValidateProgressFile(progressPath, progressBefore) },
+			Schema:   schemaJSON("progress"),
+			Restore: func() error {
+				return r.withState(func() error { return prd.RestoreProgress(progressPath, progressBefore, prdID) })
+			},
+		},
+	}, nil
+}
+
+// schemaJSON returns the named schema, or nil if it cannot be generated
+func schemaJSON(name string) []byte {
+	s, err := schema.For(name)
+	if err != nil {
+		return nil
+	}
+	data, err := s.JSON()
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// repairState validates the state files after an iteration, asking Claude to
+// fix any errors and restoring the snapshot if it cannot. Once a stop is
+// requested no repair sessions are started; invalid files are restored.
+func (r *Runner) repairState(ctx context.Context, files []repair.File) error {
+	attempts := r.cfg.Build.RepairAttempts
+	if attempts < 0 || r.Stopped() || ctx.Err() != nil {
+		attempts = 0
+	}
+	repairer := &repair.Repairer{
+		WorkspaceDir: r.workspaceDir,
+		Claude:       r.claude,
+		Model:        r.model,
+		MaxAttempts:  attempts,
+		Display:      r.display,
+	}
+
+	for _, f := range files {
+		err := repairer.Ensure(ctx, f)
+		if errors.Is(err, repair.ErrRestored) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotBaseline runs the PRD's verification commands before the executor
+// starts, so the review can tell new failures from pre-existing ones
+func (r *Runner) snapshotBaseline(ctx context.Context, p *prd.PRD) (*verify.Report, error) {
+	if r.cfg.Verify.SkipBaseline || p.Verification.IsEmpty() {
+		return nil, nil
+	}
+
+	r.display.Info("Baseline", fmt.Sprintf("Running verification for %s before changes", p.ID))
+	verifier, err := r.Verifier(p.ID, filepath.Join(workspace.RunDir(r.workspaceDir, p.ID, p.CurrentIteration), verify.BaselineDir))
+	if err != nil {
+		return nil, err
+	}
+	report, err := verifier.Run(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot baseline: %w", err)
+	}
+	if err := r.SaveFlaky(verifier); err != nil {
+		return nil, err
+	}
+	r.display.Info("Baseline", report.Summary())
+	return report, nil
+}
+
+func (r *Runner) execute(ctx context.Context, prompt, model string, tools []string) (*llm.ConsoleHandler, error) {
+	return r.executeIn(ctx, r.workspaceDir, prompt, model, tools)
+}
+
+// executeIn runs a Claude session with dir as its working directory
+func (r *Runner) executeIn(ctx context.Context, dir, prompt, model string, tools []string) (*llm.ConsoleHandler, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	handler := llm.NewConsoleHandlerWithTerminate(r.display, cancel)
+	go func() {
+		select {
+		case <-r.stop.ch:
+			handler.RequestStop()
+		case <-ctx.Done():
+		}
+	}()
+	r.display.ClaudeStart()
+
+	reader, err := r.claude.Execute(ctx, llm.ExecuteOptions{
+		Prompt:       prompt,
+		Model:        model,
+		AllowedTools: tools,
+		WorkDir:      dir,
+		OnReap:       r.reportReaped,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parseErr := llm.ParseStream(reader, handler, cancel)
+	closeErr := reader.Close()
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to read claude output: %w", parseErr)
+	}
+	// A cancelled context means we terminated Claude deliberately (signal or token limit)
+	if closeErr != nil && ctx.Err() == nil {
+		r.display.Warning(fmt.Sprintf("claude exited with error: %v", closeErr))
+	}
+
+	return handler, nil
+}
+
+// reportReaped lists the processes Claude left running that were stopped
+// when its session ended
+func (r *Runner) reportReaped(procs []proc.Process) {
+	r.display.Warning(fmt.Sprintf("stopped %d process(es) Claude left running:", len(procs)))
+	for _, p := range procs {
+		r.display.Info("Reaped", display.Truncate(p.String(), 100))
+	}
+}
+
+func (r *Runner) loadBacklog() (*prd.Backlog, error) {
+	return prd.LoadBacklog(workspace.PRDPath(r.workspaceDir))
+}
+
+// withState runs fn while holding the lock on the workspace's state files,
+// so concurrent workers don't overwrite each other's backlog and progress updates
+func (r *Runner) withState(fn func() error) error {
+	r.state.Lock()
+	defer r.state.Unlock()
+	return fn()
+}
+
+// recordAttempt closes the PRD's current attempt based on the executor's signals
+func recordAttempt(p *prd.PRD, handler *llm.ConsoleHandler, result *IterationResult) {
+	attempt := p.LastAttempt()
+	if attempt == nil {
+		return
+	}
+	attempt.EndedAt = time.Now()
+	stats := handler.GetTokenStats()
+	attempt.Tokens = stats.TotalTokens
+	attempt.CostUSD = stats.CostUSD
+
+	switch {
+	case handler.HasFailed() && handler.GetFailure().Type == llm.SignalBlocked:
+		result.Failure = handler.GetFailure()
+		attempt.Outcome = types.OutcomeBlocked
+		attempt.Blocker = result.Failure.Detail
+		p.UnblockWhen = unblockConditions(strings.Join(handler.GetCapturedOutput(), "\n"))
+		p.SetStatus(types.StatusBlocked)
+	case handler.HasFailed():
+		result.Failure = handler.GetFailure()
+		attempt.Outcome = types.OutcomeFailed
+		attempt.Observations = append(attempt.Observations,
+			fmt.Sprintf("%s: %s", result.Failure.Type, result.Failure.Detail))
+		p.SetStatus(types.StatusInProgress)
+	case handler.IsIterationComplete():
+		attempt.Outcome = types.OutcomeComplete
+		p.SetStatus(types.StatusPendingReview)
+	case handler.IsBailout():
+		attempt.Outcome = types.OutcomePartial
+		attempt.Observations = append(attempt.Observations, "bailout: "+handler.GetBailout().Detail)
+		p.SetStatus(types.StatusInProgress)
+	case handler.StopRequested():
+		attempt.Outcome = types.OutcomeInterrupted
+		attempt.Observations = append(attempt.Observations, "stopped by a signal before the iteration finished")
+		p.SetStatus(types.StatusInProgress)
+	default:
+		attempt.Outcome = types.OutcomeNoProgress
+		p.SetStatus(types.StatusInProgress)
+	}
+
+	result.Outcome = attempt.Outcome
+	result.Status = p.Status
+}
+
+// previousAttempt returns the attempt before the current one
+func previousAttempt(p *prd.PRD) *prd.Attempt {
+	if len(p.Attempts) < 2 {
+		return nil
+	}
+	return &p.Attempts[len(p.Attempts)-2]
+}
+
+// previousReview returns the review of the most recent reviewed attempt
+func previousReview(p *prd.PRD) *prd.Review {
+	for i := len(p.Attempts) - 1; i >= 0; i-- {
+		if p.Attempts[i].Review != nil {
+			return p.Attempts[i].Review
+		}
+	}
+	return nil
+}
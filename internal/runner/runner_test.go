@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/display"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// newTestWorkspace creates a git repository with one commit and a .ralph/
+// holding backlog, and returns its path
+func newTestWorkspace(t *testing.T, backlog *prd.Backlog) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	mustGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	mustGit(t, dir, "config", "user.name", "Test")
+	mustGit(t, dir, "config", "user.email", "test@example.com")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	mustGit(t, dir, "add", "main.go")
+	mustGit(t, dir, "commit", "--quiet", "-m", "initial")
+
+	if err := os.MkdirAll(workspace.Path(dir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := backlog.Save(workspace.PRDPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// newTestRunner returns a runner for dir with the default config, changed by configure
+func newTestRunner(dir string, configure func(*config.Config)) *Runner {
+	cfg := config.DefaultConfig()
+	if configure != nil {
+		configure(cfg)
+	}
+	return New(dir, cfg, display.New(), "")
+}
+
+// testPRD returns a valid PRD with the given title
+func testPRD(title string) *prd.PRD {
+	p := prd.NewPRD(title)
+	p.Description = title
+	p.AcceptanceCriteria = []string{title + " works"}
+	p.Steps = []string{"implement " + title}
+	return p
+}
+
+func mustGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadPRD(t *testing.T, dir, id string) *prd.PRD {
+	t.Helper()
+	backlog, err := prd.LoadBacklog(workspace.PRDPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := backlog.Find(id)
+	if p == nil {
+		t.Fatalf("PRD %s missing from backlog", id)
+	}
+	return p
+}
+
+func TestRepairStateRestoresOnlyItsPRD(t *testing.T) {
+	mine, other := testPRD("Mine"), testPRD("Other")
+	dir := newTestWorkspace(t, &prd.Backlog{Features: []*prd.PRD{mine, other}})
+	r := newTestRunner(dir, func(cfg *config.Config) { cfg.Build.RepairAttempts = 0 })
+
+	files, err := r.snapshotState(mine.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The executor mangled its PRD while another worker completed theirs
+	mangled, done := *mine, *other
+	mangled.Title = ""
+	done.SetStatus(types.StatusComplete)
+	if err := (&prd.Backlog{Features: []*prd.PRD{&mangled, &done}}).Save(workspace.PRDPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.repairState(context.Background(), files); err != nil {
+		t.Fatalf("repairState() error = %v", err)
+	}
+	if got := loadPRD(t, dir, mine.ID); got.Title != mine.Title {
+		t.Errorf("restored title = %q, want %q", got.Title, mine.Title)
+	}
+	if got := loadPRD(t, dir, other.ID); got.Status != types.StatusComplete {
+		t.Errorf("other PRD status = %s, want the other worker's %s", got.Status, types.StatusComplete)
+	}
+	if errs := prd.ValidateBacklogFile(workspace.PRDPath(dir), nil); errs.HasErrors() {
+		t.Errorf("restored backlog is invalid: %s", errs.Error())
+	}
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/prompts"
+)
+
+// summarizeTools lets the summarizer check claims against the code without editing it
+var summarizeTools = []string{"Read", "Glob", "Grep"}
+
+// summaryHistoryBytes caps how much entry history is sent for summarization
+const summaryHistoryBytes = 200000
+
+var codebaseStatePattern = regexp.MustCompile(`(?s)<codebase-state>\s*(.*?)\s*</codebase-state>`)
+
+// SummarizeProgress asks Claude for a concise state-of-the-codebase summary
+// of the progress history, including entries that are about to be archived
+func (r *Runner) SummarizeProgress(ctx context.Context, progress *prd.Progress, archived []prd.ProgressEntry) (string, error) {
+	base, err := prompts.GetForWorkspace(r.workspaceDir, "summarize.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to load summarize prompt: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base)
+	if progress.CodebaseState != "" {
+		sb.WriteString("\n<previous-state>\n" + progress.CodebaseState + "\n</previous-state>\n")
+	}
+	sb.WriteString("\n<history>\n")
+	sb.WriteString(historyJSON(append(append([]prd.ProgressEntry{}, archived...), progress.Entries...)))
+	sb.WriteString("\n</history>\n")
+
+	handler, err := r.execute(ctx, sb.String(), r.model, summarizeTools)
+	if err != nil {
+		return "", err
+	}
+
+	match := codebaseStatePattern.FindStringSubmatch(strings.Join(handler.GetCapturedOutput(), "\n"))
+	if match == nil || match[1] == "" {
+		return "", fmt.Errorf("summarizer did not produce a <codebase-state> section")
+	}
+	return match[1], nil
+}
+
+// historyJSON renders entries newest first, dropping the oldest once the cap is reached
+func historyJSON(entries []prd.ProgressEntry) string {
+	var lines []string
+	size := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		data, err := json.Marshal(entries[i])
+		if err != nil {
+			continue
+		}
+		if size+len(data) > summaryHistoryBytes {
+			lines = append(lines, fmt.Sprintf("(%d older entries omitted)", i+1))
+			break
+		}
+		size += len(data)
+		lines = append(lines, string(data))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// unblockCommandTimeout bounds each command condition so a hung check
+// cannot stall the loop
+const unblockCommandTimeout = time.Minute
+
+var unblockPattern = regexp.MustCompile(`###UNBLOCK_WHEN:([a-z_]+):(.+?)###`)
+
+// unblockConditions extracts the conditions the executor attached to a
+// blocker. Malformed conditions are dropped.
+func unblockConditions(output string) []prd.UnblockCondition {
+	var conditions []prd.UnblockCondition
+	for _, match := range unblockPattern.FindAllStringSubmatch(output, -1) {
+		c, err := prd.NewUnblockCondition(match[1], strings.TrimSpace(match[2]))
+		if err != nil {
+			continue
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions
+}
+
+// CheckBlocked evaluates the unblock conditions of every blocked PRD and
+// returns those whose conditions all hold to pending. A split PRD whose
+// children are all complete goes to review instead. Conditions are checked
+// without the state lock, since commands can take a while; the results are
+// applied to a freshly loaded backlog.
+func (r *Runner) CheckBlocked(ctx context.Context) error {
+	backlog, err := r.loadBacklog()
+	if err != nil {
+		return err
+	}
+
+	met := make(map[string]string) // PRD ID to the conditions that held
+	for _, p := range backlog.Features {
+		if p.Status != types.StatusBlocked || len(p.UnblockWhen) == 0 {
+			continue
+		}
+		ok, err := r.conditionsMet(ctx, backlog, p.UnblockWhen)
+		if err != nil {
+			r.display.Warning(fmt.Sprintf("%s: %v", p.ID, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		descriptions := make([]string, len(p.UnblockWhen))
+		for j, c := range p.UnblockWhen {
+			descriptions[j] = c.String()
+		}
+		met[p.ID] = strings.Join(descriptions, "; ")
+	}
+	if len(met) == 0 {
+		return nil
+	}
+
+	return r.withState(func() error {
+		backlog, err := r.loadBacklog()
+		if err != nil {
+			return err
+		}
+		unblocked := 0
+		for _, p := range backlog.Features {
+			conditions, ok := met[p.ID]
+			// Someone may have unblocked or edited it while the checks ran
+			if !ok || p.Status != types.StatusBlocked {
+				continue
+			}
+			note(p, "unblocked: "+conditions)
+			unblocked++
+			if backlog.FinishSplit(p) {
+				r.dropSplitWorktree(p)
+				r.display.Info("Unblocked", fmt.Sprintf("%s: every child is complete, awaiting review", p.ID))
+				continue
+			}
+			p.Reopen()
+			r.display.Info("Unblocked", fmt.Sprintf("%s (%s)", p.ID, conditions))
+		}
+
+		if unblocked == 0 {
+			return nil
+		}
+		return backlog.Save(workspace.PRDPath(r.workspaceDir))
+	})
+}
+
+// conditionsMet reports whether every condition holds. An error means a
+// condition could not be evaluated at all, as opposed to not holding.
+func (r *Runner) conditionsMet(ctx context.Context, backlog *prd.Backlog, conditions []prd.UnblockCondition) (bool, error) {
+	for _, c := range conditions {
+		met, err := r.conditionMet(ctx, backlog, c)
+		if err != nil || !met {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r *Runner) conditionMet(ctx context.Context, backlog *prd.Backlog, c prd.UnblockCondition) (bool, error) {
+	switch {
+	case c.Command != "":
+		return r.commandSucceeds(ctx, c.Command), nil
+	case c.FileExists != "":
+		path := c.FileExists
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.workspaceDir, path)
+		}
+		_, err := os.Stat(path)
+		return err == nil, nil
+	case c.PRDComplete != "":
+		dep := backlog.Find(c.PRDComplete)
+		if dep == nil {
+			return false, fmt.Errorf("unblock condition references unknown PRD %s", c.PRDComplete)
+		}
+		return dep.Status == types.StatusComplete, nil
+	}
+	return false, fmt.Errorf("empty unblock condition")
+}
+
+// commandSucceeds runs command through the shell from the workspace root,
+// killing its whole process group if it outlives the timeout
+func (r *Runner) commandSucceeds(ctx context.Context, command string) bool {
+	ctx, cancel := context.WithTimeout(ctx, unblockCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = r.workspaceDir
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd.Run() == nil
+}
@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/daydemir/ralph/internal/git"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+	"github.com/daydemir/ralph/internal/workspace"
+)
+
+// workDir is where a PRD's code is edited, built and verified: its worktree
+// when worktrees are enabled and one exists, otherwise the workspace
+func (r *Runner) workDir(prdID string) string {
+	if !r.cfg.Build.Worktrees {
+		return r.workspaceDir
+	}
+	root := workspace.WorktreePath(r.workspaceDir, prdID)
+	if _, err := os.Stat(root); err != nil {
+		return r.workspaceDir
+	}
+	// A workspace below the top of the repository sits at the same place in the worktree
+	return filepath.Join(root, git.Prefix(r.workspaceDir))
+}
+
+// addWorktree creates the PRD's worktree on its branch, or reuses the one
+// left by an earlier iteration
+func (r *Runner) addWorktree(prdID string) error {
+	dir := workspace.WorktreesDir(r.workspaceDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	// Keep the worktrees out of the workspace's own commits
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		if err := os.WriteFile(ignore, []byte("*\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", ignore, err)
+		}
+	}
+
+	path := workspace.WorktreePath(r.workspaceDir, prdID)
+	created, err := git.AddWorktree(r.workspaceDir, path, git.BranchName(prdID))
+	if err != nil {
+		return err
+	}
+	if created {
+		r.display.Info("Worktree", fmt.Sprintf("%s on %s", r.relPath(path), git.BranchName(prdID)))
+	}
+	return nil
+}
+
+// mergeBack brings a completed PRD's branch into the branch checked out in
+// the workspace and removes its worktree. On a conflict the worktree is
+// kept for a manual merge and the conflict is recorded on the attempt.
+func (r *Runner) mergeBack(p *prd.PRD) {
+	if !r.cfg.Build.Worktrees {
+		return
+	}
+	if !r.hasWorktree(p.ID) {
+		return
+	}
+	path := workspace.WorktreePath(r.workspaceDir, p.ID)
+
+	branch := git.BranchName(p.ID)
+	target := git.CurrentBranch(r.workspaceDir)
+	method, err := git.MergeBack(r.workspaceDir, path, branch)
+	if err != nil {
+		var conflict *git.ConflictError
+		note := fmt.Sprintf("merge-back failed: %v; worktree kept at %s", err, r.relPath(path))
+		if errors.As(err, &conflict) {
+			note = fmt.Sprintf("merge-back conflict: %v; resolve it in %s and merge %s by hand", conflict, r.relPath(path), branch)
+		}
+		// A conflict is retried after every later merge; record it once
+		if attempt := p.LastAttempt(); attempt != nil && !lastObservation(attempt, note) {
+			attempt.Observations = append(attempt.Observations, note)
+		}
+		r.display.Warning(note)
+		return
+	}
+
+	r.display.Success(fmt.Sprintf("merged %s into %s (%s)", branch, target, method))
+	if err := git.RemoveWorktree(r.workspaceDir, path); err != nil {
+		r.display.Warning(err.Error())
+	}
+}
+
+// dropSplitWorktree removes the worktree of a split PRD whose children are
+// complete, so its review sees the children's merged work instead of its
+// own stale attempt. Its branch is kept.
+func (r *Runner) dropSplitWorktree(p *prd.PRD) {
+	if !r.cfg.Build.Worktrees || !r.hasWorktree(p.ID) {
+		return
+	}
+	path := workspace.WorktreePath(r.workspaceDir, p.ID)
+	if err := git.RemoveWorktree(r.workspaceDir, path); err != nil {
+		r.display.Warning(err.Error())
+		return
+	}
+	note(p, fmt.Sprintf("removed worktree %s; its own attempts stay on %s", r.relPath(path), git.BranchName(p.ID)))
+}
+
+// mergeCompleted merges every completed PRD whose worktree is still around,
+// dependencies first. A PRD waits until the PRDs it depends on are merged,
+// so a conflict holds back its dependents too. Without worktrees the PRD
+// branches are merged in the workspace itself.
+func (r *Runner) mergeCompleted(backlog *prd.Backlog) {
+	if !r.cfg.Build.Worktrees {
+		r.mergeBranches(backlog)
+		return
+	}
+	for _, p := range backlog.InDependencyOrder() {
+		if p.Status == types.StatusComplete && r.hasWorktree(p.ID) && r.dependenciesMerged(p) {
+			r.mergeBack(p)
+		}
+	}
+}
+
+// dependenciesMerged reports whether none of p's dependencies still has a
+// worktree waiting to be merged
+func (r *Runner) dependenciesMerged(p *prd.PRD) bool {
+	for _, id := range p.DependsOn {
+		if r.hasWorktree(id) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Runner) hasWorktree(prdID string) bool {
+	_, err := os.Stat(workspace.WorktreePath(r.workspaceDir, prdID))
+	return err == nil
+}
+
+func lastObservation(a *prd.Attempt, note string) bool {
+	return len(a.Observations) > 0 && a.Observations[len(a.Observations)-1] == note
+}
+
+// relPath shows path relative to the workspace when it can
+func (r *Runner) relPath(path string) string {
+	rel, err := filepath.Rel(r.workspaceDir, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/daydemir/ralph/internal/config"
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+var minOne, zero = 1, 0
+
+// required marks a property that must be present
+var required = Rule{Required: true}
+
+// requiredText marks a property that must be present and non-empty
+var requiredText = Rule{Required: true, NonEmpty: true}
+
+// documents are the files Ralph can describe. The rules mirror the
+// ValidateWithDetails checks for each type; keep them in step.
+var documents = map[string]func() *Schema{
+	"prd": func() *Schema {
+		return Generate(prd.Backlog{}, Options{
+			Title:       "Ralph PRD backlog",
+			Description: "The ordered list of PRDs in .ralph/prd.json",
+			Tag:         "json",
+			Rules: Rules{
+				reflect.TypeOf(prd.Backlog{}): {
+					"features": required,
+				},
+				reflect.TypeOf(prd.PRD{}): {
+					"version":             requiredText,
+					"id":                  requiredText,
+					"title":               requiredText,
+					"description":         requiredText,
+					"acceptance_criteria": requiredText,
+					"steps":               requiredText,
+					"created_at":          required,
+					"updated_at":          required,
+					"max_iterations":      {Required: true, Minimum: &minOne},
+				},
+			},
+		})
+	},
+	"progress": func() *Schema {
+		return Generate(prd.Progress{}, Options{
+			Title:       "Ralph progress",
+			Description: "Append-only iteration history, learnings and patterns in .ralph/progress.json",
+			Tag:         "json",
+			Rules: Rules{
+				reflect.TypeOf(prd.Progress{}): {
+					"schema_version": requiredText,
+					"created_at":     required,
+				},
+				reflect.TypeOf(prd.ProgressEntry{}): {
+					"id":               requiredText,
+					"timestamp":        required,
+					"prd_id":           requiredText,
+					"iteration":        {Required: true, Minimum: &minOne},
+					"status":           required,
+					"duration_seconds": {Minimum: &zero},
+				},
+				reflect.TypeOf(prd.ProgressObs{}): {
+					"type":  required,
+					"title": requiredText,
+				},
+				reflect.TypeOf(prd.Learning{}): {
+					"id":         requiredText,
+					"content":    requiredText,
+					"created_at": required,
+				},
+				reflect.TypeOf(prd.Pattern{}): {
+					"id":            requiredText,
+					"name":          requiredText,
+					"discovered_at": required,
+				},
+			},
+		})
+	},
+	"context": func() *Schema {
+		return Generate(types.Context{}, Options{
+			Title:       "Ralph project context",
+			Description: "Project-level context in context.json",
+			Tag:         "json",
+			Rules: Rules{
+				reflect.TypeOf(types.Context{}): {
+					"version":    requiredText,
+					"created_at": required,
+					"updated_at": required,
+				},
+			},
+		})
+	},
+	"config": func() *Schema {
+		// Every config value has a default, so nothing is required
+		return Generate(config.Config{}, Options{
+			Title:       "Ralph config",
+			Description: "Workspace settings in .ralph/config.yaml",
+			Tag:         "mapstructure",
+		})
+	},
+}
+
+// Names returns the documents For accepts, sorted
+func Names() []string {
+	var names []string
+	for name := range documents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// For returns the schema for a named document: prd, progress, context or config
+func For(name string) (*Schema, error) {
+	build, ok := documents[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q, must be one of: %v", name, Names())
+	}
+	return build(), nil
+}
@@ -0,0 +1,211 @@
+// Package schema derives JSON Schema documents for Ralph's state and config
+// files from the Go types that read them, so the schema cannot drift from
+// the code.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daydemir/ralph/internal/types"
+)
+
+// Draft is the JSON Schema dialect emitted by Generate
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema that Ralph's types need
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	Minimum     *int               `json:"minimum,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Additional  *Schema            `json:"additionalProperties,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Rule constrains a single property beyond what its Go type implies
+type Rule struct {
+	Required bool
+	NonEmpty bool // strings need at least one character, arrays at least one item
+	Minimum  *int
+}
+
+// Rules maps a struct type to constraints on its properties, keyed by the
+// property name as it appears in the file
+type Rules map[reflect.Type]map[string]Rule
+
+// Options control how Go types are mapped to a schema
+type Options struct {
+	Title       string
+	Description string
+	Tag         string // struct tag holding property names: "json" or "mapstructure"
+	Rules       Rules
+}
+
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// enums maps named string types to their allowed values
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(types.Status("")):            values(types.AllStatuses()),
+	reflect.TypeOf(types.Outcome("")):           values(types.AllOutcomes()),
+	reflect.TypeOf(types.ProgressStatus("")):    values(types.AllProgressStatuses()),
+	reflect.TypeOf(types.ObservationType("")):   values(types.AllObservationTypes()),
+	reflect.TypeOf(types.ObsCategory("")):       values(types.AllObsCategories()),
+	reflect.TypeOf(types.ObsSeverity("")):       values(types.AllObsSeverities()),
+	reflect.TypeOf(types.ObsAction("")):         values(types.AllObsActions()),
+	reflect.TypeOf(types.RecoveryAction("")):    values(types.AllRecoveryActions()),
+	reflect.TypeOf(types.LearningType("")):      values(types.AllLearningTypes()),
+	reflect.TypeOf(types.PatternType("")):       values(types.AllPatternTypes()),
+	reflect.TypeOf(types.PatternConfidence("")): values(types.AllPatternConfidences()),
+	reflect.TypeOf(types.EscalationPolicy("")):  values(types.AllEscalationPolicies()),
+	reflect.TypeOf(types.RollbackPolicy("")):    values(types.AllRollbackPolicies()),
+	reflect.TypeOf(types.InterruptAction("")):   values(types.AllInterruptActions()),
+}
+
+// Generate builds a schema for v's type. Nested structs are emitted once
+// under $defs and referenced by name.
+func Generate(v any, opts Options) *Schema {
+	g := &generator{opts: opts, defs: make(map[string]*Schema)}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	root := g.structSchema(t)
+	root.Schema = Draft
+	root.Title = opts.Title
+	root.Description = opts.Description
+	if len(g.defs) > 0 {
+		root.Defs = g.defs
+	}
+	return root
+}
+
+// JSON returns the schema as indented JSON
+func (s *Schema) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return data, nil
+}
+
+type generator struct {
+	opts Options
+	defs map[string]*Schema
+}
+
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if values, ok := enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return &Schema{Type: "string", Pattern: durationPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", Additional: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, seen := g.defs[name]; !seen {
+			g.defs[name] = nil // reserve the name so recursive types terminate
+			g.defs[name] = g.structSchema(t)
+		}
+		return &Schema{Ref: "#/$defs/" + name}
+	}
+	return &Schema{}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	rules := g.opts.Rules[t]
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := g.propertyName(field)
+		if name == "" {
+			continue
+		}
+
+		prop := g.schemaFor(field.Type)
+		rule := rules[name]
+		if rule.NonEmpty {
+			one := 1
+			switch prop.Type {
+			case "string":
+				prop.MinLength = &one
+			case "array":
+				prop.MinItems = &one
+			}
+		}
+		if rule.Minimum != nil {
+			prop.Minimum = rule.Minimum
+		}
+		if rule.Required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+
+	sort.Strings(s.Required)
+	return s
+}
+
+// propertyName returns the name a field is stored under, or "" if it is skipped
+func (g *generator) propertyName(field reflect.StructField) string {
+	tag := field.Tag.Get(g.opts.Tag)
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// values converts a list of string enum values to plain strings
+func values[T ~string](list []T) []string {
+	out := make([]string, len(list))
+	for i, v := range list {
+		out[i] = string(v)
+	}
+	return out
+}
@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/types"
+)
+
+func TestRequiredMirrorsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		def    string
+		errors *types.ValidationErrors
+	}{
+		{"prd", "PRD", (&prd.PRD{}).ValidateWithDetails()},
+		{"progress", "", (&prd.Progress{}).ValidateWithDetails()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := For(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target := s
+			if tt.def != "" {
+				target = s.Defs[tt.def]
+			}
+
+			required := make(map[string]bool)
+			for _, name := range target.Required {
+				required[name] = true
+			}
+			for _, e := range tt.errors.Errors {
+				if !required[e.Field] {
+					t.Errorf("validation requires %q but the schema does not", e.Field)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusEnum(t *testing.T) {
+	s, err := For("prd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for _, status := range types.AllStatuses() {
+		want = append(want, status.String())
+	}
+	if got := s.Defs["PRD"].Properties["status"].Enum; !reflect.DeepEqual(got, want) {
+		t.Errorf("status enum = %v, want %v", got, want)
+	}
+}
+
+func TestConfigUsesMapstructureNames(t *testing.T) {
+	s, err := For("config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := s.Defs["BuildConfig"]
+	if build == nil || build.Properties["default_loop_iterations"] == nil {
+		t.Fatalf("BuildConfig missing default_loop_iterations: %+v", build)
+	}
+	if got := s.Defs["VerifyConfig"].Properties["timeout"].Type; got != "string" {
+		t.Errorf("timeout type = %q, want string", got)
+	}
+}
+
+func TestForUnknown(t *testing.T) {
+	if _, err := For("nope"); err == nil || !strings.Contains(err.Error(), "must be one of") {
+		t.Errorf("For(nope) error = %v", err)
+	}
+}
+
+func TestBacklogMidRunMatchesSchema(t *testing.T) {
+	s, err := For("prd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := prd.NewPRD("Login")
+	p.Description = "Users can log in"
+	p.AcceptanceCriteria = []string{"login works"}
+	p.Steps = []string{"add the handler"}
+	p.StartAttempt("abc1234")
+	p.Attempts[0].EndedAt = time.Now()
+	p.Attempts[0].Outcome = types.OutcomePartial
+	p.StartAttempt("def5678") // still running
+
+	data, err := json.Marshal(&prd.Backlog{Features: []*prd.PRD{p}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range conform(s, s, doc, "$") {
+		t.Error(problem)
+	}
+}
+
+// conform checks v against the subset of JSON Schema that Generate emits
+// and returns what doesn't match
+func conform(root, s *Schema, v any, path string) []string {
+	if s.Ref != "" {
+		return conform(root, root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")], v, path)
+	}
+
+	var problems []string
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not an object", path, v)}
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required %q", path, name))
+			}
+		}
+		for name, value := range obj {
+			prop := s.Properties[name]
+			if prop == nil {
+				prop = s.Additional
+			}
+			if prop != nil {
+				problems = append(problems, conform(root, prop, value, path+"."+name)...)
+			}
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			if v == nil {
+				return nil // nil slices marshal as null
+			}
+			return []string{fmt.Sprintf("%s: %v is not an array", path, v)}
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			problems = append(problems, fmt.Sprintf("%s: fewer than %d items", path, *s.MinItems))
+		}
+		for i, item := range items {
+			problems = append(problems, conform(root, s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not a string", path, v)}
+		}
+		if s.Enum != nil && !slices.Contains(s.Enum, str) {
+			problems = append(problems, fmt.Sprintf("%s: %q is not one of %v", path, str, s.Enum))
+		}
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			problems = append(problems, fmt.Sprintf("%s: shorter than %d", path, *s.MinLength))
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not a number", path, v)}
+		}
+		if s.Minimum != nil && n < float64(*s.Minimum) {
+			problems = append(problems, fmt.Sprintf("%s: %v is below %d", path, n, *s.Minimum))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %v is not a boolean", path, v))
+		}
+	}
+	return problems
+}
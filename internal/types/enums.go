@@ -35,3 +35,387 @@ func AllStatuses() []Status {
 func (s Status) String() string {
 	return string(s)
 }
+
+// Outcome represents the result of a single PRD execution attempt
+type Outcome string
+
+const (
+	// OutcomePartial indicates some steps were completed before the iteration ended
+	OutcomePartial Outcome = "partial"
+	// OutcomeComplete indicates the executor claimed all work was done
+	OutcomeComplete Outcome = "complete"
+	// OutcomeBlocked indicates the executor reported a blocker
+	OutcomeBlocked Outcome = "blocked"
+	// OutcomeFailed indicates the executor emitted a failure signal
+	OutcomeFailed Outcome = "failed"
+	// OutcomeNoProgress indicates the iteration ended without any signal
+	OutcomeNoProgress Outcome = "no_progress"
+	// OutcomeInterrupted indicates Ralph was stopped or killed before the iteration ended
+	OutcomeInterrupted Outcome = "interrupted"
+)
+
+// AllOutcomes returns all valid outcome values
+func AllOutcomes() []Outcome {
+	return []Outcome{OutcomePartial, OutcomeComplete, OutcomeBlocked, OutcomeFailed, OutcomeNoProgress, OutcomeInterrupted}
+}
+
+// String returns the string representation of the outcome
+func (o Outcome) String() string {
+	return string(o)
+}
+
+// ProgressStatus is the outcome of an iteration as recorded in progress.json
+type ProgressStatus string
+
+const (
+	// ProgressCompleted indicates the iteration's work passed review
+	ProgressCompleted ProgressStatus = "completed"
+	// ProgressFailed indicates the iteration failed or its completion was rejected
+	ProgressFailed ProgressStatus = "failed"
+	// ProgressBlocked indicates the iteration reported a blocker
+	ProgressBlocked ProgressStatus = "blocked"
+	// ProgressPartial indicates the iteration ended with work remaining
+	ProgressPartial ProgressStatus = "partial"
+)
+
+// IsValid checks if a progress status is valid
+func (s ProgressStatus) IsValid() bool {
+	for _, valid := range AllProgressStatuses() {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllProgressStatuses returns all valid progress status values
+func AllProgressStatuses() []ProgressStatus {
+	return []ProgressStatus{ProgressCompleted, ProgressFailed, ProgressBlocked, ProgressPartial}
+}
+
+// ObservationType classifies a finding captured during an iteration
+type ObservationType string
+
+const (
+	// ObsBlocker indicates something that prevents the work from continuing
+	ObsBlocker ObservationType = "blocker"
+	// ObsFinding indicates something noticed along the way
+	ObsFinding ObservationType = "finding"
+	// ObsCompletion indicates work that turned out to be already done
+	ObsCompletion ObservationType = "completion"
+)
+
+// IsValid checks if an observation type is valid
+func (t ObservationType) IsValid() bool {
+	for _, valid := range AllObservationTypes() {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllObservationTypes returns all valid observation types
+func AllObservationTypes() []ObservationType {
+	return []ObservationType{ObsBlocker, ObsFinding, ObsCompletion}
+}
+
+// ObsCategory is the inferred category of an observation, used for pattern analysis
+type ObsCategory string
+
+const (
+	ObsCatBug             ObsCategory = "bug"
+	ObsCatStub            ObsCategory = "stub"
+	ObsCatDependency      ObsCategory = "dependency"
+	ObsCatScopeCreep      ObsCategory = "scope-creep"
+	ObsCatAPIIssue        ObsCategory = "api-issue"
+	ObsCatTestFailure     ObsCategory = "test-failure"
+	ObsCatToolingFriction ObsCategory = "tooling-friction"
+	ObsCatArchitecture    ObsCategory = "architecture"
+	ObsCatDocumentation   ObsCategory = "documentation"
+	ObsCatPerformance     ObsCategory = "performance"
+	ObsCatSecurity        ObsCategory = "security"
+)
+
+// IsValid checks if an observation category is valid; empty is allowed
+func (c ObsCategory) IsValid() bool {
+	if c == "" {
+		return true
+	}
+	for _, valid := range AllObsCategories() {
+		if c == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllObsCategories returns all valid observation categories
+func AllObsCategories() []ObsCategory {
+	return []ObsCategory{
+		ObsCatBug, ObsCatStub, ObsCatDependency, ObsCatScopeCreep, ObsCatAPIIssue, ObsCatTestFailure,
+		ObsCatToolingFriction, ObsCatArchitecture, ObsCatDocumentation, ObsCatPerformance, ObsCatSecurity,
+	}
+}
+
+// ObsSeverity is the severity of an observation
+type ObsSeverity string
+
+const (
+	ObsSevCritical ObsSeverity = "critical"
+	ObsSevHigh     ObsSeverity = "high"
+	ObsSevMedium   ObsSeverity = "medium"
+	ObsSevLow      ObsSeverity = "low"
+	ObsSevInfo     ObsSeverity = "info"
+)
+
+// IsValid checks if an observation severity is valid; empty is allowed
+func (s ObsSeverity) IsValid() bool {
+	if s == "" {
+		return true
+	}
+	for _, valid := range AllObsSeverities() {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllObsSeverities returns all valid observation severities
+func AllObsSeverities() []ObsSeverity {
+	return []ObsSeverity{ObsSevCritical, ObsSevHigh, ObsSevMedium, ObsSevLow, ObsSevInfo}
+}
+
+// ObsAction is the action taken on an observation
+type ObsAction string
+
+const (
+	ObsActionFixed      ObsAction = "fixed"
+	ObsActionDeferred   ObsAction = "deferred"
+	ObsActionEscalated  ObsAction = "escalated"
+	ObsActionDocumented ObsAction = "documented"
+	ObsActionNone       ObsAction = "none"
+)
+
+// IsValid checks if an observation action is valid; empty is allowed
+func (a ObsAction) IsValid() bool {
+	if a == "" {
+		return true
+	}
+	for _, valid := range AllObsActions() {
+		if a == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllObsActions returns all valid observation actions
+func AllObsActions() []ObsAction {
+	return []ObsAction{ObsActionFixed, ObsActionDeferred, ObsActionEscalated, ObsActionDocumented, ObsActionNone}
+}
+
+// RecoveryAction is the action taken to recover from a previous iteration
+type RecoveryAction string
+
+const (
+	RecoveryRetry       RecoveryAction = "retry"
+	RecoveryFixState    RecoveryAction = "fix-state"
+	RecoveryBreakChunks RecoveryAction = "break-chunks"
+	RecoverySkip        RecoveryAction = "skip"
+	RecoveryManual      RecoveryAction = "manual"
+)
+
+// IsValid checks if a recovery action is valid; empty is allowed
+func (a RecoveryAction) IsValid() bool {
+	if a == "" {
+		return true
+	}
+	for _, valid := range AllRecoveryActions() {
+		if a == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllRecoveryActions returns all valid recovery actions
+func AllRecoveryActions() []RecoveryAction {
+	return []RecoveryAction{RecoveryRetry, RecoveryFixState, RecoveryBreakChunks, RecoverySkip, RecoveryManual}
+}
+
+// LearningType classifies a persistent learning
+type LearningType string
+
+const (
+	LearningCodebasePattern        LearningType = "codebase-pattern"
+	LearningBuildCommand           LearningType = "build-command"
+	LearningTestPattern            LearningType = "test-pattern"
+	LearningAPIConvention          LearningType = "api-convention"
+	LearningErrorWorkaround        LearningType = "error-workaround"
+	LearningToolUsage              LearningType = "tool-usage"
+	LearningArchitectureConstraint LearningType = "architecture-constraint"
+	LearningDependencyQuirk        LearningType = "dependency-quirk"
+)
+
+// IsValid checks if a learning type is valid; empty is allowed for
+// learnings upgraded from files that did not classify them
+func (t LearningType) IsValid() bool {
+	if t == "" {
+		return true
+	}
+	for _, valid := range AllLearningTypes() {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllLearningTypes returns all valid learning types
+func AllLearningTypes() []LearningType {
+	return []LearningType{
+		LearningCodebasePattern, LearningBuildCommand, LearningTestPattern, LearningAPIConvention,
+		LearningErrorWorkaround, LearningToolUsage, LearningArchitectureConstraint, LearningDependencyQuirk,
+	}
+}
+
+// PatternType classifies a codebase pattern
+type PatternType string
+
+const (
+	PatternFileStructure    PatternType = "file-structure"
+	PatternNamingConvention PatternType = "naming-convention"
+	PatternAPI              PatternType = "api-pattern"
+	PatternTest             PatternType = "test-pattern"
+	PatternErrorHandling    PatternType = "error-handling"
+	PatternStateManagement  PatternType = "state-management"
+	PatternBuild            PatternType = "build-pattern"
+	PatternDeployment       PatternType = "deployment-pattern"
+)
+
+// IsValid checks if a pattern type is valid; empty is allowed for
+// patterns upgraded from files that did not classify them
+func (t PatternType) IsValid() bool {
+	if t == "" {
+		return true
+	}
+	for _, valid := range AllPatternTypes() {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllPatternTypes returns all valid pattern types
+func AllPatternTypes() []PatternType {
+	return []PatternType{
+		PatternFileStructure, PatternNamingConvention, PatternAPI, PatternTest,
+		PatternErrorHandling, PatternStateManagement, PatternBuild, PatternDeployment,
+	}
+}
+
+// PatternConfidence is how confident Ralph is that a pattern is real
+type PatternConfidence string
+
+const (
+	ConfidenceHigh   PatternConfidence = "high"
+	ConfidenceMedium PatternConfidence = "medium"
+	ConfidenceLow    PatternConfidence = "low"
+)
+
+// IsValid checks if a pattern confidence is valid; empty is allowed
+func (c PatternConfidence) IsValid() bool {
+	return c == "" || c == ConfidenceHigh || c == ConfidenceMedium || c == ConfidenceLow
+}
+
+// AllPatternConfidences returns all valid pattern confidence values
+func AllPatternConfidences() []PatternConfidence {
+	return []PatternConfidence{ConfidenceHigh, ConfidenceMedium, ConfidenceLow}
+}
+
+// EscalationPolicy decides what happens to a PRD that has used all of its iterations
+type EscalationPolicy string
+
+const (
+	EscalateBlock         EscalationPolicy = "block"          // mark blocked until unblocked
+	EscalateSplit         EscalationPolicy = "split"          // break into smaller PRDs via a planning session
+	EscalateStrongerModel EscalationPolicy = "stronger_model" // retry with the escalation model
+	EscalateHumanReview   EscalationPolicy = "human_review"   // mark blocked and stop the loop
+)
+
+// IsValid checks if an escalation policy is valid; empty is allowed and
+// means the configured default applies
+func (e EscalationPolicy) IsValid() bool {
+	if e == "" {
+		return true
+	}
+	for _, valid := range AllEscalationPolicies() {
+		if e == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllEscalationPolicies returns all valid escalation policies
+func AllEscalationPolicies() []EscalationPolicy {
+	return []EscalationPolicy{EscalateBlock, EscalateSplit, EscalateStrongerModel, EscalateHumanReview}
+}
+
+// RollbackPolicy decides what happens to the working tree after a failed iteration
+type RollbackPolicy string
+
+const (
+	RollbackKeep  RollbackPolicy = "keep"  // leave the failed changes for the next iteration
+	RollbackReset RollbackPolicy = "reset" // save the failed diff as a patch and reset to the pre-iteration commit
+)
+
+// IsValid checks if a rollback policy is valid; empty is allowed and means
+// the configured default applies
+func (p RollbackPolicy) IsValid() bool {
+	if p == "" {
+		return true
+	}
+	for _, valid := range AllRollbackPolicies() {
+		if p == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllRollbackPolicies returns all valid rollback policies
+func AllRollbackPolicies() []RollbackPolicy {
+	return []RollbackPolicy{RollbackKeep, RollbackReset}
+}
+
+// InterruptAction decides what happens to an iteration a killed Ralph process left unfinished
+type InterruptAction string
+
+const (
+	InterruptResume InterruptAction = "resume" // commit the leftover changes and keep the PRD in progress
+	InterruptReset  InterruptAction = "reset"  // save the leftover changes as a patch, reset the tree and return the PRD to pending
+	InterruptBlock  InterruptAction = "block"  // commit the leftover changes and block the PRD for a person to look at
+)
+
+// IsValid checks if an interrupt action is valid; empty is allowed and means
+// Ralph asks
+func (a InterruptAction) IsValid() bool {
+	if a == "" {
+		return true
+	}
+	for _, valid := range AllInterruptActions() {
+		if a == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// AllInterruptActions returns all valid interrupt actions
+func AllInterruptActions() []InterruptAction {
+	return []InterruptAction{InterruptResume, InterruptReset, InterruptBlock}
+}
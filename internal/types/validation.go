@@ -28,6 +28,20 @@ func (v *ValidationErrors) Add(field, expected string, actual interface{}, msg s
 	})
 }
 
+// Merge appends another collection's errors, prefixing each field path
+// Example: prefix "features[2]" turns "title" into "features[2].title"
+func (v *ValidationErrors) Merge(prefix string, other *ValidationErrors) {
+	if other == nil {
+		return
+	}
+	for _, e := range other.Errors {
+		if prefix != "" {
+			e.Field = prefix + "." + e.Field
+		}
+		v.Errors = append(v.Errors, e)
+	}
+}
+
 // HasErrors returns true if there are any validation errors
 func (v *ValidationErrors) HasErrors() bool {
 	return len(v.Errors) > 0
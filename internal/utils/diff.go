@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// UnifiedDiff returns a unified diff between two versions of a file, or ""
+// if they are identical. Uses the system diff tool.
+func UnifiedDiff(label string, before, after []byte) (string, error) {
+	dir, err := os.MkdirTemp("", "ralph-diff-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	beforePath := filepath.Join(dir, "before")
+	afterPath := filepath.Join(dir, "after")
+	if err := os.WriteFile(beforePath, before, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", beforePath, err)
+	}
+	if err := os.WriteFile(afterPath, after, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", afterPath, err)
+	}
+
+	out, err := exec.Command("diff", "-u", "-L", "a/"+label, "-L", "b/"+label, beforePath, afterPath).Output()
+	var exitErr *exec.ExitError
+	// diff exits 1 when the files differ
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", fmt.Errorf("failed to diff %s: %w", label, err)
+	}
+	return string(out), nil
+}
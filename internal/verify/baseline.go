@@ -0,0 +1,143 @@
+package verify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Comparison describes how verification results changed relative to a
+// baseline taken before the iteration started
+type Comparison struct {
+	NewFailures []string `json:"new_failures,omitempty"` // failing now, passing or absent before
+	Fixed       []string `json:"fixed,omitempty"`        // failing before, passing now
+	PreExisting []string `json:"pre_existing,omitempty"` // failing both before and now
+}
+
+// HasRegressions returns true if the iteration introduced any new failure
+func (c *Comparison) HasRegressions() bool {
+	return len(c.NewFailures) > 0
+}
+
+// Summary returns a one-line description such as
+// "introduced 2 new failures, fixed 1, 3 pre-existing"
+func (c *Comparison) Summary() string {
+	if len(c.NewFailures) == 0 && len(c.Fixed) == 0 && len(c.PreExisting) == 0 {
+		return "no failures before or after"
+	}
+	parts := []string{fmt.Sprintf("introduced %d new %s", len(c.NewFailures), plural(len(c.NewFailures), "failure", "failures"))}
+	if len(c.Fixed) > 0 {
+		parts = append(parts, fmt.Sprintf("fixed %d", len(c.Fixed)))
+	}
+	if len(c.PreExisting) > 0 {
+		parts = append(parts, fmt.Sprintf("%d pre-existing", len(c.PreExisting)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Compare matches current results against a baseline command by command.
+// Commands whose output contains parsed test results are compared per test;
+// other commands are compared on their exit status. A nil baseline treats
+// every current failure as new.
+func Compare(baseline, current *Report) *Comparison {
+	before := make(map[string]CommandResult)
+	if baseline != nil {
+		for _, res := range baseline.Results {
+			before[res.Command] = res
+		}
+	}
+
+	c := &Comparison{}
+	for _, now := range current.Results {
+		prev, hadBaseline := before[now.Command]
+
+		if failingTests(now) != nil || failingTests(prev) != nil {
+			c.compareTests(prev, now, hadBaseline)
+			continue
+		}
+
+		label := "command: " + now.Command
+		switch {
+		case !now.Passed && hadBaseline && !prev.Passed:
+			c.PreExisting = append(c.PreExisting, label)
+		case !now.Passed:
+			c.NewFailures = append(c.NewFailures, label)
+		case hadBaseline && !prev.Passed:
+			c.Fixed = append(c.Fixed, label)
+		}
+	}
+
+	sort.Strings(c.NewFailures)
+	sort.Strings(c.Fixed)
+	sort.Strings(c.PreExisting)
+	return c
+}
+
+// compareTests classifies per-test changes for a single command
+func (c *Comparison) compareTests(prev, now CommandResult, hadBaseline bool) {
+	wasFailing := failingTests(prev)
+	isFailing := failingTests(now)
+
+	for name := range isFailing {
+		if wasFailing[name] {
+			c.PreExisting = append(c.PreExisting, name)
+		} else {
+			c.NewFailures = append(c.NewFailures, name)
+		}
+	}
+	// Only count a test as fixed if it actually ran and passed; a test that
+	// disappeared (e.g. the package no longer compiles) is not a fix
+	for name := range wasFailing {
+		if passedIn(now, name) {
+			c.Fixed = append(c.Fixed, name)
+		}
+	}
+
+	// A command can fail without any failing test (compile error, crash,
+	// timeout); that is a regression unless it was already failing that way
+	if !now.Passed && len(isFailing) == 0 {
+		label := "command: " + now.Command
+		if hadBaseline && !prev.Passed && len(wasFailing) == 0 {
+			c.PreExisting = append(c.PreExisting, label)
+		} else {
+			c.NewFailures = append(c.NewFailures, label)
+		}
+	}
+}
+
+// failingTests returns the set of failing test names, or nil if the command
+// produced no parsed test results
+func failingTests(res CommandResult) map[string]bool {
+	if len(res.Tests) == 0 {
+		return nil
+	}
+	failing := make(map[string]bool)
+	for _, t := range res.Tests {
+		if t.Status == TestFailed {
+			failing[t.Name] = true
+		}
+	}
+	return failing
+}
+
+// passedIn reports whether the named test ran and passed every time it ran
+func passedIn(res CommandResult, name string) bool {
+	ran := false
+	for _, t := range res.Tests {
+		if t.Name != name {
+			continue
+		}
+		if t.Status != TestPassed {
+			return false
+		}
+		ran = true
+	}
+	return ran
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,131 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/daydemir/ralph/internal/utils"
+)
+
+// FlakyExpiry is how long a flaky test stays in the registry after it was
+// last seen flaking
+const FlakyExpiry = 30 * 24 * time.Hour
+
+// FlakyTest records a test (or whole command) that has failed and then
+// passed on rerun without any code change
+type FlakyTest struct {
+	Name      string    `json:"name"`
+	Command   string    `json:"command"`
+	Count     int       `json:"count"` // times it has been observed flaking
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// FlakyRegistry is the persistent list of known flaky tests (.ralph/flaky.json).
+// It is context for the executor only: verification never passes a failure
+// because its test is listed here.
+type FlakyRegistry struct {
+	Tests []FlakyTest `json:"tests"`
+}
+
+// LoadFlakyRegistry reads the registry, returning an empty one if the file does not exist
+func LoadFlakyRegistry(path string) (*FlakyRegistry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &FlakyRegistry{Tests: []FlakyTest{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var registry FlakyRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &registry, nil
+}
+
+// Save writes the registry to disk
+func (f *FlakyRegistry) Save(path string) error {
+	sort.Slice(f.Tests, func(i, j int) bool {
+		return f.Tests[i].Name < f.Tests[j].Name
+	})
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal flaky registry: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsFlaky reports whether the named test is in the registry
+func (f *FlakyRegistry) IsFlaky(name string) bool {
+	return f.find(name) != nil
+}
+
+// Merge adds the observations in other, such as the flakes a single
+// verification run recorded, to the registry
+func (f *FlakyRegistry) Merge(other *FlakyRegistry) {
+	for _, t := range other.Tests {
+		existing := f.find(t.Name)
+		if existing == nil {
+			f.Tests = append(f.Tests, t)
+			continue
+		}
+		existing.Count += t.Count
+		existing.Command = t.Command
+		if t.FirstSeen.Before(existing.FirstSeen) {
+			existing.FirstSeen = t.FirstSeen
+		}
+		if t.LastSeen.After(existing.LastSeen) {
+			existing.LastSeen = t.LastSeen
+		}
+	}
+}
+
+// Prune drops tests that haven't flaked within FlakyExpiry of now
+func (f *FlakyRegistry) Prune(now time.Time) {
+	kept := f.Tests[:0]
+	for _, t := range f.Tests {
+		if now.Sub(t.LastSeen) < FlakyExpiry {
+			kept = append(kept, t)
+		}
+	}
+	f.Tests = kept
+}
+
+// Record adds a flake observation, creating the entry if needed
+func (f *FlakyRegistry) Record(name, command string) {
+	now := time.Now()
+	if existing := f.find(name); existing != nil {
+		existing.Count++
+		existing.LastSeen = now
+		existing.Command = command
+		return
+	}
+	f.Tests = append(f.Tests, FlakyTest{
+		Name:      name,
+		Command:   command,
+		Count:     1,
+		FirstSeen: now,
+		LastSeen:  now,
+	})
+}
+
+func (f *FlakyRegistry) find(name string) *FlakyTest {
+	for i := range f.Tests {
+		if f.Tests[i].Name == name {
+			return &f.Tests[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package verify
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFlakyRegistryMergePrune(t *testing.T) {
+	now := time.Now()
+	registry := &FlakyRegistry{Tests: []FlakyTest{
+		{Name: "login", Command: "npm test", Count: 2, FirstSeen: now.Add(-48 * time.Hour), LastSeen: now.Add(-24 * time.Hour)},
+		{Name: "stale", Command: "npm test", Count: 5, FirstSeen: now.Add(-2 * FlakyExpiry), LastSeen: now.Add(-FlakyExpiry - time.Hour)},
+	}}
+
+	// Observations from another worker's verification run
+	run := &FlakyRegistry{}
+	run.Record("login", "npm run test")
+	run.Record("signup", "npm test")
+	registry.Merge(run)
+	registry.Prune(now.Add(time.Minute))
+
+	path := filepath.Join(t.TempDir(), "flaky.json")
+	if err := registry.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadFlakyRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadFlakyRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		wantCount int // 0 means not in the registry
+		wantCmd   string
+	}{
+		{"login", 3, "npm run test"},
+		{"signup", 1, "npm test"},
+		{"stale", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loaded.find(tt.name)
+			if tt.wantCount == 0 {
+				if got != nil {
+					t.Errorf("find(%q) = %+v, want pruned", tt.name, got)
+				}
+				return
+			}
+			if got == nil || got.Count != tt.wantCount || got.Command != tt.wantCmd {
+				t.Errorf("find(%q) = %+v, want count %d command %q", tt.name, got, tt.wantCount, tt.wantCmd)
+			}
+		})
+	}
+	if login := loaded.find("login"); login != nil && !login.FirstSeen.Equal(now.Add(-48*time.Hour)) {
+		t.Errorf("login FirstSeen = %v, want the earlier observation kept", login.FirstSeen)
+	}
+}
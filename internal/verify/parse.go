@@ -0,0 +1,174 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// TestStatus is the outcome of a single test case
+type TestStatus string
+
+const (
+	TestPassed  TestStatus = "pass"
+	TestFailed  TestStatus = "fail"
+	TestSkipped TestStatus = "skip"
+	TestFlaky   TestStatus = "flaky" // failed, then passed on rerun
+)
+
+// TestResult is a single test case parsed from verification output
+type TestResult struct {
+	Name   string     `json:"name"`
+	Status TestStatus `json:"status"`
+}
+
+// Output formats recognized by ParseTestOutput
+const (
+	FormatGoTestJSON = "go-test-json"
+	FormatJUnit      = "junit"
+	FormatTAP        = "tap"
+)
+
+// ParseTestOutput detects the format of a command's output and extracts
+// per-test results. Supports `go test -json`, JUnit XML and TAP. Returns an
+// empty format when the output is not recognized.
+func ParseTestOutput(output []byte) (string, []TestResult) {
+	if results := parseGoTestJSON(output); len(results) > 0 {
+		return FormatGoTestJSON, results
+	}
+	if results := parseJUnit(output); len(results) > 0 {
+		return FormatJUnit, results
+	}
+	if results := parseTAP(output); len(results) > 0 {
+		return FormatTAP, results
+	}
+	return "", nil
+}
+
+// goTestEvent is a single line of `go test -json` output
+type goTestEvent struct {
+	Action  string `json:"Action"`
+	Package string `json:"Package"`
+	Test    string `json:"Test"`
+}
+
+func parseGoTestJSON(output []byte) []TestResult {
+	var results []TestResult
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var event goTestEvent
+		if err := json.Unmarshal(line, &event); err != nil || event.Test == "" {
+			continue
+		}
+
+		var status TestStatus
+		switch event.Action {
+		case "pass":
+			status = TestPassed
+		case "fail":
+			status = TestFailed
+		case "skip":
+			status = TestSkipped
+		default:
+			continue
+		}
+		results = append(results, TestResult{Name: event.Package + "/" + event.Test, Status: status})
+	}
+
+	return results
+}
+
+// junitTestCase is a <testcase> element in a JUnit XML report
+type junitTestCase struct {
+	ClassName string    `xml:"classname,attr"`
+	Name      string    `xml:"name,attr"`
+	Failure   *struct{} `xml:"failure"`
+	Error     *struct{} `xml:"error"`
+	Skipped   *struct{} `xml:"skipped"`
+}
+
+func parseJUnit(output []byte) []TestResult {
+	start := bytes.Index(output, []byte("<testsuite"))
+	if start < 0 {
+		return nil
+	}
+
+	var results []TestResult
+	decoder := xml.NewDecoder(bytes.NewReader(output[start:]))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF || err != nil {
+			break
+		}
+		el, ok := token.(xml.StartElement)
+		if !ok || el.Name.Local != "testcase" {
+			continue
+		}
+
+		var tc junitTestCase
+		if err := decoder.DecodeElement(&tc, &el); err != nil {
+			break
+		}
+
+		name := tc.Name
+		if tc.ClassName != "" {
+			name = tc.ClassName + "." + tc.Name
+		}
+		status := TestPassed
+		switch {
+		case tc.Failure != nil || tc.Error != nil:
+			status = TestFailed
+		case tc.Skipped != nil:
+			status = TestSkipped
+		}
+		results = append(results, TestResult{Name: name, Status: status})
+	}
+
+	return results
+}
+
+var tapLinePattern = regexp.MustCompile(`^(not )?ok\b(?:\s+(\d+))?(?:\s*-)?\s*([^#]*?)\s*(?:#\s*(\w+).*)?$`)
+
+func parseTAP(output []byte) []TestResult {
+	var results []TestResult
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+
+	for scanner.Scan() {
+		match := tapLinePattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+
+		name := match[3]
+		if name == "" {
+			name = "test " + match[2]
+		}
+
+		status := TestPassed
+		if match[1] != "" {
+			status = TestFailed
+		}
+		// TODO tests are expected to fail and SKIP tests did not run
+		switch strings.ToUpper(match[4]) {
+		case "SKIP":
+			status = TestSkipped
+		case "TODO":
+			if status == TestFailed {
+				status = TestSkipped
+			}
+		}
+		results = append(results, TestResult{Name: name, Status: status})
+	}
+
+	return results
+}
@@ -0,0 +1,149 @@
+package verify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTestOutput(t *testing.T) {
+	testCases := []struct {
+		name       string
+		output     string
+		wantFormat string
+		want       []TestResult
+	}{
+		{
+			name: "go test -json",
+			output: `{"Action":"run","Package":"example.com/auth","Test":"TestLogin"}
+{"Action":"output","Package":"example.com/auth","Test":"TestLogin","Output":"--- FAIL"}
+{"Action":"fail","Package":"example.com/auth","Test":"TestLogin","Elapsed":0.01}
+{"Action":"pass","Package":"example.com/auth","Test":"TestLogout","Elapsed":0.01}
+{"Action":"skip","Package":"example.com/auth","Test":"TestOAuth","Elapsed":0}
+{"Action":"fail","Package":"example.com/auth","Elapsed":0.02}`,
+			wantFormat: FormatGoTestJSON,
+			want: []TestResult{
+				{Name: "example.com/auth/TestLogin", Status: TestFailed},
+				{Name: "example.com/auth/TestLogout", Status: TestPassed},
+				{Name: "example.com/auth/TestOAuth", Status: TestSkipped},
+			},
+		},
+		{
+			name: "junit xml with leading noise",
+			output: `Running tests...
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="auth">
+    <testcase classname="auth.Login" name="accepts valid password"/>
+    <testcase classname="auth.Login" name="rejects bad password"><failure message="expected 401"/></testcase>
+    <testcase classname="auth.Login" name="locks account"><error/></testcase>
+    <testcase classname="auth.Login" name="sso"><skipped/></testcase>
+  </testsuite>
+</testsuites>`,
+			wantFormat: FormatJUnit,
+			want: []TestResult{
+				{Name: "auth.Login.accepts valid password", Status: TestPassed},
+				{Name: "auth.Login.rejects bad password", Status: TestFailed},
+				{Name: "auth.Login.locks account", Status: TestFailed},
+				{Name: "auth.Login.sso", Status: TestSkipped},
+			},
+		},
+		{
+			name: "tap",
+			output: `TAP version 13
+1..5
+ok 1 - login works
+not ok 2 - logout works
+ok 3 - oauth # SKIP no credentials
+not ok 4 - sso # TODO not implemented
+ok 5`,
+			wantFormat: FormatTAP,
+			want: []TestResult{
+				{Name: "login works", Status: TestPassed},
+				{Name: "logout works", Status: TestFailed},
+				{Name: "oauth", Status: TestSkipped},
+				{Name: "sso", Status: TestSkipped},
+				{Name: "test 5", Status: TestPassed},
+			},
+		},
+		{
+			name:   "unrecognized output",
+			output: "Build succeeded.\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, got := ParseTestOutput([]byte(tc.output))
+			if format != tc.wantFormat {
+				t.Errorf("format = %q, want %q", format, tc.wantFormat)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("results = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := func(statuses map[string]TestStatus) []TestResult {
+		var results []TestResult
+		for name, status := range statuses {
+			results = append(results, TestResult{Name: name, Status: status})
+		}
+		return results
+	}
+
+	baseline := &Report{Results: []CommandResult{
+		{Command: "go test -json ./...", Passed: false, Tests: tests(map[string]TestStatus{
+			"a/TestOld": TestFailed, "a/TestFixed": TestFailed, "a/TestStable": TestPassed,
+		})},
+		{Command: "npm run lint", Passed: false},
+		{Command: "go build ./...", Passed: true},
+	}}
+	current := &Report{Results: []CommandResult{
+		{Command: "go test -json ./...", Passed: false, Tests: tests(map[string]TestStatus{
+			"a/TestOld": TestFailed, "a/TestFixed": TestPassed, "a/TestStable": TestFailed, "a/TestNew": TestFailed,
+		})},
+		{Command: "npm run lint", Passed: false},
+		{Command: "go build ./...", Passed: true},
+	}}
+
+	c := Compare(baseline, current)
+	if want := []string{"a/TestNew", "a/TestStable"}; !reflect.DeepEqual(c.NewFailures, want) {
+		t.Errorf("NewFailures = %v, want %v", c.NewFailures, want)
+	}
+	if want := []string{"a/TestFixed"}; !reflect.DeepEqual(c.Fixed, want) {
+		t.Errorf("Fixed = %v, want %v", c.Fixed, want)
+	}
+	if want := []string{"a/TestOld", "command: npm run lint"}; !reflect.DeepEqual(c.PreExisting, want) {
+		t.Errorf("PreExisting = %v, want %v", c.PreExisting, want)
+	}
+	if got, want := c.Summary(), "introduced 2 new failures, fixed 1, 2 pre-existing"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestCompareCompileFailure(t *testing.T) {
+	baseline := &Report{Results: []CommandResult{
+		{Command: "go test -json ./...", Passed: false, Tests: []TestResult{{Name: "a/TestOld", Status: TestFailed}}},
+	}}
+	// Package no longer compiles: no test events, command fails
+	current := &Report{Results: []CommandResult{
+		{Command: "go test -json ./...", Passed: false},
+	}}
+
+	c := Compare(baseline, current)
+	if len(c.Fixed) != 0 {
+		t.Errorf("Fixed = %v, want none (test did not run)", c.Fixed)
+	}
+	if !c.HasRegressions() {
+		t.Error("HasRegressions() = false, want true for compile failure")
+	}
+}
+
+func TestCompareWithoutBaseline(t *testing.T) {
+	current := &Report{Results: []CommandResult{{Command: "make test", Passed: false}}}
+	if c := Compare(nil, current); !c.HasRegressions() {
+		t.Error("HasRegressions() = false, want true when there is no baseline")
+	}
+}
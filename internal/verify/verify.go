@@ -0,0 +1,328 @@
+// Package verify runs a PRD's verification commands independently of the
+// executor, so completion claims can be checked against real results.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/daydemir/ralph/internal/prd"
+	"github.com/daydemir/ralph/internal/utils"
+)
+
+// DefaultTimeout bounds a single verification command when none is configured
+const DefaultTimeout = 10 * time.Minute
+
+// waitDelay is how long to wait for output pipes after a timed-out command is killed
+const waitDelay = 5 * time.Second
+
+// tailBytes is how much of each stream is kept inline in the report
+const tailBytes = 4000
+
+// ReportFile is the name of the report written to the evidence directory
+const ReportFile = "report.json"
+
+// Evidence subdirectories within a run directory
+const (
+	BaselineDir = "baseline" // results captured before the iteration started
+	ResultsDir  = "verify"   // results captured when the completion claim is reviewed
+)
+
+// CommandResult is the outcome of a single verification command
+type CommandResult struct {
+	Kind       string        `json:"kind"` // build, type_check, tests, custom
+	Command    string        `json:"command"`
+	ExitCode   int           `json:"exit_code"`
+	Passed     bool          `json:"passed"`
+	TimedOut   bool          `json:"timed_out,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration_ns"`
+	StdoutPath string        `json:"stdout_path,omitempty"`
+	StderrPath string        `json:"stderr_path,omitempty"`
+	OutputTail string        `json:"output_tail,omitempty"` // last few KB of stdout+stderr
+	Error      string        `json:"error,omitempty"`       // set when the command could not be started
+	Format     string        `json:"format,omitempty"`      // detected test output format, if any
+	Tests      []TestResult  `json:"tests,omitempty"`       // per-test results parsed from stdout
+	Reruns     int           `json:"reruns,omitempty"`      // extra runs made to classify failures
+	Flaky      []string      `json:"flaky,omitempty"`       // failures classified as flaky and ignored
+}
+
+// Report aggregates the results of all verification commands for a PRD
+type Report struct {
+	PRDID     string          `json:"prd_id"`
+	StartedAt time.Time       `json:"started_at"`
+	Duration  time.Duration   `json:"duration_ns"`
+	Passed    bool            `json:"passed"`
+	Results   []CommandResult `json:"results"`
+	Path      string          `json:"-"` // where the report was written, empty if not persisted
+}
+
+// Failures returns the commands that did not succeed
+func (r *Report) Failures() []CommandResult {
+	var failed []CommandResult
+	for _, res := range r.Results {
+		if !res.Passed {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Summary returns a one-line description of the report
+func (r *Report) Summary() string {
+	if len(r.Results) == 0 {
+		return "no verification commands defined"
+	}
+	failed := r.Failures()
+	if len(failed) == 0 {
+		return fmt.Sprintf("%d/%d verification commands passed", len(r.Results), len(r.Results))
+	}
+	var names []string
+	for _, f := range failed {
+		if f.TimedOut {
+			names = append(names, fmt.Sprintf("%s (timed out)", f.Command))
+		} else {
+			names = append(names, fmt.Sprintf("%s (exit %d)", f.Command, f.ExitCode))
+		}
+	}
+	return fmt.Sprintf("%d/%d verification commands failed: %s", len(failed), len(r.Results), strings.Join(names, ", "))
+}
+
+// Runner executes verification commands in a working directory
+type Runner struct {
+	WorkDir     string
+	Timeout     time.Duration  // per command; DefaultTimeout when zero
+	EvidenceDir string         // where stdout/stderr and report.json are written; skipped when empty
+	Reruns      int            // times a failing command is rerun to detect flaky tests
+	Flaky       *FlakyRegistry // records failures that passed on rerun when set
+}
+
+// Run executes the PRD's verification commands in the order build,
+// type_check, tests, custom. Every command runs even if an earlier one
+// fails, so the report shows the full picture. The returned error is only
+// set when evidence could not be written.
+func (r *Runner) Run(ctx context.Context, p *prd.PRD) (*Report, error) {
+	report := &Report{
+		PRDID:     p.ID,
+		StartedAt: time.Now(),
+		Passed:    true,
+	}
+
+	if r.EvidenceDir != "" {
+		if err := os.MkdirAll(r.EvidenceDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create evidence directory: %w", err)
+		}
+	}
+
+	index := 0
+	for _, group := range commandGroups(p.Verification) {
+		for _, command := range group.commands {
+			index++
+			result, err := r.runCommand(ctx, index, group.kind, command)
+			if err != nil {
+				return nil, err
+			}
+			report.Results = append(report.Results, result)
+			if !result.Passed {
+				report.Passed = false
+			}
+		}
+	}
+	report.Duration = time.Since(report.StartedAt)
+
+	if r.EvidenceDir != "" {
+		if err := report.save(filepath.Join(r.EvidenceDir, ReportFile)); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+type commandGroup struct {
+	kind     string
+	commands []string
+}
+
+func commandGroups(v prd.Verification) []commandGroup {
+	return []commandGroup{
+		{kind: "build", commands: v.Build},
+		{kind: "type_check", commands: v.TypeCheck},
+		{kind: "tests", commands: v.Tests},
+		{kind: "custom", commands: v.Custom},
+	}
+}
+
+func (r *Runner) runCommand(ctx context.Context, index int, kind, command string) (CommandResult, error) {
+	base := fmt.Sprintf("%02d-%s", index, kind)
+	result, err := r.execCommand(ctx, kind, command, base)
+	if err != nil || result.Passed {
+		return result, err
+	}
+
+	flaky := make(map[string]bool)
+	failing := failingTests(result)
+	for i := 1; i <= r.Reruns && ctx.Err() == nil; i++ {
+		rerun, err := r.execCommand(ctx, kind, command, fmt.Sprintf("%s.rerun%d", base, i))
+		if err != nil {
+			return result, err
+		}
+		result.Reruns++
+
+		if len(failing) == 0 {
+			// No per-test results: the command as a whole is flaky if it passes on rerun
+			if rerun.Passed {
+				flaky["command: "+command] = true
+				break
+			}
+			continue
+		}
+		for name := range failing {
+			if passedIn(rerun, name) {
+				flaky[name] = true
+			}
+		}
+		if len(flaky) == len(failing) {
+			break
+		}
+	}
+
+	r.classifyFlaky(&result, failing, flaky)
+	return result, nil
+}
+
+// classifyFlaky marks failures that passed on rerun as flaky and records
+// them. The command counts as passed when every failure it had was flaky.
+func (r *Runner) classifyFlaky(result *CommandResult, failing, flaky map[string]bool) {
+	for name := range flaky {
+		if r.Flaky != nil {
+			r.Flaky.Record(name, result.Command)
+		}
+	}
+
+	for i := range result.Tests {
+		if result.Tests[i].Status == TestFailed && flaky[result.Tests[i].Name] {
+			result.Tests[i].Status = TestFlaky
+		}
+	}
+	for name := range flaky {
+		result.Flaky = append(result.Flaky, name)
+	}
+	sort.Strings(result.Flaky)
+
+	if len(failing) == 0 {
+		result.Passed = flaky["command: "+result.Command]
+		return
+	}
+	result.Passed = true
+	for name := range failing {
+		if !flaky[name] {
+			result.Passed = false
+		}
+	}
+}
+
+// execCommand runs a command once, saving its output as evidence under base
+func (r *Runner) execCommand(ctx context.Context, kind, command, base string) (CommandResult, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(cmdCtx, "sh", "-c", command)
+	cmd.Dir = r.WorkDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Run in its own process group so a timeout also kills anything the command spawned
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
+
+	result := CommandResult{
+		Kind:      kind,
+		Command:   command,
+		StartedAt: time.Now(),
+	}
+	err := cmd.Run()
+	result.Duration = time.Since(result.StartedAt)
+	result.Passed = err == nil
+	result.TimedOut = errors.Is(cmdCtx.Err(), context.DeadlineExceeded)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.ExitCode = -1
+		result.Error = err.Error()
+	}
+
+	result.Format, result.Tests = ParseTestOutput(stdout.Bytes())
+	result.OutputTail = tail(strings.TrimSpace(stdout.String()+"\n"+stderr.String()), tailBytes)
+
+	if r.EvidenceDir != "" {
+		result.StdoutPath = filepath.Join(r.EvidenceDir, base+".stdout.log")
+		result.StderrPath = filepath.Join(r.EvidenceDir, base+".stderr.log")
+		if err := os.WriteFile(result.StdoutPath, stdout.Bytes(), 0644); err != nil {
+			return result, fmt.Errorf("failed to write %s: %w", result.StdoutPath, err)
+		}
+		if err := os.WriteFile(result.StderrPath, stderr.Bytes(), 0644); err != nil {
+			return result, fmt.Errorf("failed to write %s: %w", result.StderrPath, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *Report) save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal verification report: %w", err)
+	}
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return err
+	}
+	r.Path = path
+	return nil
+}
+
+// LoadReport reads a report previously written to an evidence directory
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	report.Path = path
+
+	return &report, nil
+}
+
+// tail returns the last n bytes of s
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
@@ -0,0 +1,138 @@
+package verify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/daydemir/ralph/internal/prd"
+)
+
+func TestRunnerRun(t *testing.T) {
+	dir := t.TempDir()
+	evidence := filepath.Join(dir, "evidence")
+
+	p := &prd.PRD{
+		ID: "auth-login-a1b2",
+		Verification: prd.Verification{
+			Tests: []string{"echo out; echo err >&2; exit 3"},
+			Build: []string{"true"},
+		},
+	}
+
+	runner := &Runner{WorkDir: dir, EvidenceDir: evidence}
+	report, err := runner.Run(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Passed {
+		t.Error("report.Passed = true, want false")
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(report.Results))
+	}
+	if report.Results[0].Kind != "build" || !report.Results[0].Passed {
+		t.Errorf("first result = %+v, want passing build", report.Results[0])
+	}
+
+	tests := report.Results[1]
+	if tests.ExitCode != 3 {
+		t.Errorf("exit code = %d, want 3", tests.ExitCode)
+	}
+	stdout, _ := os.ReadFile(tests.StdoutPath)
+	stderr, _ := os.ReadFile(tests.StderrPath)
+	if string(stdout) != "out\n" || string(stderr) != "err\n" {
+		t.Errorf("evidence stdout=%q stderr=%q", stdout, stderr)
+	}
+
+	loaded, err := LoadReport(report.Path)
+	if err != nil {
+		t.Fatalf("LoadReport() error = %v", err)
+	}
+	if loaded.PRDID != p.ID || len(loaded.Results) != 2 {
+		t.Errorf("loaded report = %+v", loaded)
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	p := &prd.PRD{
+		ID:           "slow-0000",
+		Verification: prd.Verification{Custom: []string{"sleep 5"}},
+	}
+
+	runner := &Runner{WorkDir: t.TempDir(), Timeout: 100 * time.Millisecond}
+	report, err := runner.Run(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !report.Results[0].TimedOut || report.Passed {
+		t.Errorf("result = %+v, want timed out failure", report.Results[0])
+	}
+}
+
+func TestRunnerFlakyRerun(t *testing.T) {
+	dir := t.TempDir()
+
+	// Fails on the first run only, emitting TAP so the flake is tracked per test
+	flakyCmd := `if [ -f marker ]; then echo "ok 1 - login"; else touch marker; echo "not ok 1 - login"; exit 1; fi`
+	p := &prd.PRD{
+		ID:           "flaky-0000",
+		Verification: prd.Verification{Tests: []string{flakyCmd, "echo 'not ok 1 - broken'; exit 1"}},
+	}
+
+	registry := &FlakyRegistry{}
+	runner := &Runner{WorkDir: dir, Reruns: 2, Flaky: registry}
+	report, err := runner.Run(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	flaky := report.Results[0]
+	if !flaky.Passed || flaky.Reruns != 1 || len(flaky.Flaky) != 1 || flaky.Flaky[0] != "login" {
+		t.Errorf("flaky result = %+v, want passed after 1 rerun with login flaky", flaky)
+	}
+	if !registry.IsFlaky("login") {
+		t.Error("registry does not contain login")
+	}
+
+	broken := report.Results[1]
+	if broken.Passed || broken.Reruns != 2 || len(broken.Flaky) != 0 {
+		t.Errorf("broken result = %+v, want failure after 2 reruns", broken)
+	}
+	if report.Passed {
+		t.Error("report.Passed = true, want false")
+	}
+}
+
+func TestRunnerRegistryDoesNotPassFailures(t *testing.T) {
+	dir := t.TempDir()
+	p := &prd.PRD{
+		ID: "regressed-0000",
+		Verification: prd.Verification{
+			Build: []string{"exit 1"},
+			Tests: []string{"echo 'not ok 1 - login'; exit 1"},
+		},
+	}
+
+	// Both flaked before; failing on every rerun now is a real regression
+	registry := &FlakyRegistry{}
+	registry.Record("login", "earlier")
+	registry.Record("command: exit 1", "exit 1")
+	runner := &Runner{WorkDir: dir, Reruns: 1, Flaky: registry}
+	report, err := runner.Run(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for _, res := range report.Results {
+		if res.Passed || len(res.Flaky) != 0 {
+			t.Errorf("result = %+v, want failure with nothing flaky", res)
+		}
+	}
+	if report.Passed {
+		t.Error("report.Passed = true, want false")
+	}
+}
@@ -135,9 +135,22 @@ mistral:
 
 build:
   default_loop_iterations: 10
+  repair_attempts: 2       # Sessions to fix invalid prd.json/progress.json after an iteration (-1 disables)
+  learnings_limit: 10      # Most relevant learnings inlined into each build prompt (-1 disables)
+  learnings_budget: 4000   # Max bytes of learnings inlined into each build prompt
+  escalation: block        # When a PRD hits max_iterations: block, split, stronger_model or human_review
+  escalation_model: opus   # Model used by the stronger_model escalation
+  split_after_bailouts: 2  # Consecutive bailouts that split a PRD into smaller ones (-1 disables)
+  rollback: keep           # After a failed iteration: keep the changes, or reset (saving them as a patch)
+  worktrees: false         # Run each PRD in its own git worktree under .ralph/worktrees/
   signals:
     iteration_complete: "###ITERATION_COMPLETE###"
     ralph_complete: "###RALPH_COMPLETE###"
+
+verify:
+  timeout: 10m             # Per-command timeout for PRD verification
+  skip_baseline: false     # Skip the pre-iteration baseline (all failures count as new)
+  flaky_reruns: 2          # Rerun failing commands to detect flaky tests (-1 disables)
 `
 
 const defaultPRD = `{
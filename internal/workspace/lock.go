@@ -0,0 +1,167 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LockFile is the name of the workspace lock inside .ralph/
+const LockFile = "lock"
+
+// LockInfo describes the process holding a workspace lock
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// LockedError is returned when another live process holds the workspace lock
+type LockedError struct {
+	Path   string
+	Holder LockInfo
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("workspace is in use by '%s' (pid %d on %s, since %s); wait for it to finish, or delete %s if that process is gone",
+		e.Holder.Command, e.Holder.PID, e.Holder.Host, e.Holder.StartedAt.Format(time.DateTime), e.Path)
+}
+
+// Lock is an advisory lock on a workspace's state files
+type Lock struct {
+	path string
+}
+
+// LockPath returns the workspace lock file path
+func LockPath(workspaceDir string) string {
+	return filepath.Join(workspaceDir, RalphDir, LockFile)
+}
+
+// AcquireLock takes the workspace lock for this process. A lock left behind
+// by a process that is no longer running is taken over. Returns a
+// *LockedError when a live process holds it.
+func AcquireLock(workspaceDir string) (*Lock, error) {
+	path := LockPath(workspaceDir)
+	host, _ := os.Hostname()
+	info := LockInfo{
+		PID:       os.Getpid(),
+		Host:      host,
+		Command:   strings.Join(append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...), " "),
+		StartedAt: time.Now(),
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock: %w", err)
+	}
+
+	// One retry covers replacing a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		err := createExclusive(path, data)
+		if err == nil {
+			// Make sure no takeover replaced the lock we just linked
+			if holder, _ := readLock(path, host); holder.PID != info.PID || holder.Host != host {
+				return nil, &LockedError{Path: path, Holder: holder}
+			}
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+
+		holder, stale := readLock(path, host)
+		if !stale {
+			return nil, &LockedError{Path: path, Holder: holder}
+		}
+		if err := removeStale(path, host); err != nil {
+			return nil, err
+		}
+	}
+	holder, _ := readLock(path, host)
+	return nil, &LockedError{Path: path, Holder: holder}
+}
+
+// Release removes the lock. Releasing a nil lock is a no-op.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release lock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// createExclusive writes data to path only if path doesn't exist. The lock
+// is written to a temp file and linked into place, so other processes never
+// see a half-written lock.
+func createExclusive(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".lock.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Link(tmp.Name(), path)
+}
+
+// removeStale deletes the lock at path if it is still stale. Takeovers hold
+// an flock on the lock's directory and check the lock again under it, so a
+// process that found the same stale lock can't delete the fresh lock that
+// replaced it.
+func removeStale(path, host string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Dir(path), err)
+	}
+	defer dir.Close()
+	if err := syscall.Flock(int(dir.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", filepath.Dir(path), err)
+	}
+	defer syscall.Flock(int(dir.Fd()), syscall.LOCK_UN)
+
+	if _, stale := readLock(path, host); !stale {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale lock %s: %w", path, err)
+	}
+	return nil
+}
+
+// readLock reads the lock at path and reports whether it is stale: its
+// process on this host is gone, or the file is unreadable. Locks from other
+// hosts can't be checked and are never stale.
+func readLock(path, host string) (LockInfo, bool) {
+	var info LockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Released between our create and read; try again
+		return info, os.IsNotExist(err)
+	}
+	if err := json.Unmarshal(data, &info); err != nil || info.PID <= 0 {
+		return info, true
+	}
+	if info.Host != host {
+		return info, false
+	}
+	return info, !processAlive(info.PID)
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
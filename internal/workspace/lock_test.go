@@ -0,0 +1,118 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	host, _ := os.Hostname()
+
+	tests := []struct {
+		name       string
+		existing   string
+		wantLocked bool
+	}{
+		{"no lock", "", false},
+		{"live holder", lockJSON(t, LockInfo{PID: os.Getpid(), Host: host}), true},
+		{"dead holder", lockJSON(t, LockInfo{PID: deadPID(t), Host: host}), false},
+		{"holder on another host", lockJSON(t, LockInfo{PID: deadPID(t), Host: host + "-elsewhere"}), true},
+		{"corrupt lock", `{"pid":`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, RalphDir), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != "" {
+				if err := os.WriteFile(LockPath(dir), []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			lock, err := AcquireLock(dir)
+			var locked *LockedError
+			if errors.As(err, &locked) != tt.wantLocked {
+				t.Fatalf("AcquireLock() error = %v, wantLocked %v", err, tt.wantLocked)
+			}
+			if tt.wantLocked {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := AcquireLock(dir); !errors.As(err, &locked) || locked.Holder.PID != os.Getpid() {
+				t.Errorf("second AcquireLock() error = %v, want held by pid %d", err, os.Getpid())
+			}
+			if err := lock.Release(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(LockPath(dir)); !os.IsNotExist(err) {
+				t.Errorf("lock file still exists after Release: %v", err)
+			}
+		})
+	}
+}
+
+func TestRemoveStale(t *testing.T) {
+	host, _ := os.Hostname()
+
+	tests := []struct {
+		name        string
+		current     string // the lock on disk when the takeover runs; "" for none
+		wantRemoved bool
+	}{
+		{"still stale", lockJSON(t, LockInfo{PID: deadPID(t), Host: host}), true},
+		// Another process took over first; its fresh lock must survive
+		{"replaced by a live lock", lockJSON(t, LockInfo{PID: os.Getpid(), Host: host}), false},
+		{"already removed", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, RalphDir), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.current != "" {
+				if err := os.WriteFile(LockPath(dir), []byte(tt.current), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := removeStale(LockPath(dir), host); err != nil {
+				t.Fatalf("removeStale() error = %v", err)
+			}
+			_, err := os.Stat(LockPath(dir))
+			if removed := os.IsNotExist(err); removed != tt.wantRemoved {
+				t.Errorf("lock removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func lockJSON(t *testing.T, info LockInfo) string {
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// deadPID returns the PID of a process that has exited
+func deadPID(t *testing.T) int {
+	p, err := os.StartProcess("/bin/true", []string{"true"}, &os.ProcAttr{})
+	if err != nil {
+		t.Skipf("cannot start a process: %v", err)
+	}
+	if _, err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	return p.Pid
+}
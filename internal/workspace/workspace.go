@@ -2,6 +2,7 @@ package workspace
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -46,3 +47,34 @@ func ConfigPath(workspaceDir string) string {
 func PRDPath(workspaceDir string) string {
 	return filepath.Join(workspaceDir, RalphDir, "prd.json")
 }
+
+// ProgressPath returns the progress.json path
+func ProgressPath(workspaceDir string) string {
+	return filepath.Join(workspaceDir, RalphDir, "progress.json")
+}
+
+// ProgressArchiveDir returns the directory compacted progress entries are moved to
+func ProgressArchiveDir(workspaceDir string) string {
+	return filepath.Join(workspaceDir, RalphDir, "progress-archive")
+}
+
+// FlakyPath returns the flaky test registry path
+func FlakyPath(workspaceDir string) string {
+	return filepath.Join(workspaceDir, RalphDir, "flaky.json")
+}
+
+// WorktreesDir returns the directory holding per-PRD git worktrees
+func WorktreesDir(workspaceDir string) string {
+	return filepath.Join(workspaceDir, RalphDir, "worktrees")
+}
+
+// WorktreePath returns the git worktree a PRD is executed in
+func WorktreePath(workspaceDir, prdID string) string {
+	return filepath.Join(WorktreesDir(workspaceDir), prdID)
+}
+
+// RunDir returns the directory holding artifacts for one PRD iteration
+// Format: .ralph/runs/{prd-id}-{iteration}
+func RunDir(workspaceDir, prdID string, iteration int) string {
+	return filepath.Join(workspaceDir, RalphDir, "runs", fmt.Sprintf("%s-%d", prdID, iteration))
+}